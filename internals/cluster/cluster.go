@@ -0,0 +1,453 @@
+// Package cluster lets multiple inmem-pubsub processes form a mesh and
+// forward locally-published messages to peers that have local subscribers
+// for the same topic, turning the single-process broker into a
+// horizontally scalable one without an external dependency like Kafka.
+//
+// Each node dials a configured set of peer addresses and keeps one
+// outbound writer stream per peer, buffered and non-blocking so a slow or
+// unreachable peer can't stall local publishing (see peer.enqueue).
+// Peers periodically push the set of topics they have local subscribers
+// for; a node only forwards a publish to peers that have said they care.
+// Forwarded messages carry the origin node's ID and a per-node sequence
+// number; a receiving node publishes them locally (see Message.Origin in
+// internals/models) but does not forward them again, which is what keeps a
+// message from looping around the mesh forever.
+//
+// Two deliberate simplifications versus a production gossip library
+// (memberlist/serf): the wire format is length-prefixed JSON rather than
+// protobuf (matching the JSON framing already used for WebSocket/SSE
+// delivery elsewhere in this repo), and membership is a static, symmetric
+// mesh seeded via Config.Seeds plus addresses peers announce about
+// themselves on connect, rather than a full SWIM/epidemic gossip protocol.
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+const (
+	// defaultOutboxSize bounds how many not-yet-sent frames a peer writer
+	// will buffer before Forward starts dropping for that peer.
+	defaultOutboxSize = 256
+
+	// defaultGossipInterval is how often a node pushes its topic interest
+	// snapshot to every peer, when Config.GossipInterval is unset.
+	defaultGossipInterval = 5 * time.Second
+
+	// defaultDialTimeout bounds connecting to a peer, when
+	// Config.DialTimeout is unset.
+	defaultDialTimeout = 5 * time.Second
+
+	// reconnectInterval is how long a dial loop waits after a failed or
+	// dropped connection before retrying.
+	reconnectInterval = 2 * time.Second
+
+	// dedupTTL bounds how long a (origin, seq) pair is remembered to
+	// reject a duplicate forwarded publish, and how often the dedup cache
+	// is purged of entries older than that.
+	dedupTTL = 5 * time.Minute
+)
+
+// ClusterTransport forwards locally-published messages to peer nodes and
+// reports this node's topic interest to them. topicManagerService depends
+// on this interface rather than *Gossip directly so single-node
+// deployments can use Noop at zero cost.
+type ClusterTransport interface {
+	// Forward enqueues msg for delivery to every peer that has reported
+	// local subscribers for topicName. It returns immediately; delivery is
+	// asynchronous and best-effort. A msg whose Origin is already set (it
+	// arrived forwarded from a peer) is ignored, so messages aren't
+	// relayed more than one hop.
+	Forward(topicName string, msg models.Message)
+
+	// UpdateInterest reports whether this node currently has local
+	// subscribers for topicName, so peers know whether to forward future
+	// publishes here.
+	UpdateInterest(topicName string, hasSubscribers bool)
+
+	// Close stops gossiping and closes every peer connection.
+	Close() error
+}
+
+// Noop is the default ClusterTransport: every call is a no-op. Used when
+// cluster mode isn't configured (config.Config.ClusterBindAddr is empty).
+type Noop struct{}
+
+func (Noop) Forward(string, models.Message) {}
+func (Noop) UpdateInterest(string, bool)    {}
+func (Noop) Close() error                   { return nil }
+
+// Config configures a Gossip cluster member.
+type Config struct {
+	// NodeID uniquely identifies this node to peers, for message dedup and
+	// loop prevention. A random one is generated if empty.
+	NodeID string
+
+	// BindAddr is the "host:port" this node listens on for peer
+	// connections, and the address it announces to peers it dials so they
+	// can dial back. Required to enable cluster mode.
+	BindAddr string
+
+	// Seeds lists peer "host:port" addresses to dial on startup. A peer
+	// that connects to us announces its own BindAddr, and we start dialing
+	// it too if it wasn't already in Seeds, so the mesh fills in as long
+	// as it's seeded symmetrically somewhere.
+	Seeds []string
+
+	// GossipInterval is how often this node pushes its topic interest
+	// snapshot to every peer. Defaults to defaultGossipInterval.
+	GossipInterval time.Duration
+
+	// DialTimeout bounds connecting to a seed or reconnecting to a dropped
+	// peer. Defaults to defaultDialTimeout.
+	DialTimeout time.Duration
+}
+
+// dedupKey identifies a forwarded message for loop/duplicate suppression.
+type dedupKey struct {
+	origin string
+	seq    uint64
+}
+
+// Gossip is the ClusterTransport implementation backing cluster mode: it
+// maintains an outbound stream to every known peer, accepts inbound
+// connections from peers dialing us, and exchanges topic-interest and
+// forwarded-publish frames with them. See the package doc for the wire
+// format and gossip simplifications.
+type Gossip struct {
+	nodeID   string
+	bindAddr string
+	cfg      Config
+
+	localPublish func(topicName string, msg models.Message) error
+
+	listener net.Listener
+
+	peersMu sync.Mutex
+	peers   map[string]*peer // addr -> peer
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{} // inbound connections accepted from peers
+
+	localInterestMu sync.RWMutex
+	localInterest   map[string]struct{}
+
+	seqCounter uint64
+
+	dedupMu sync.Mutex
+	dedup   map[dedupKey]time.Time
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// New creates a Gossip node listening on cfg.BindAddr, dials cfg.Seeds, and
+// starts its background gossip, accept, and dedup-purge loops. Incoming
+// forwarded publishes are handed to localPublish, which should deliver them
+// to this node's local subscribers (e.g. topicManagerService.Publish).
+func New(cfg Config, localPublish func(topicName string, msg models.Message) error) (*Gossip, error) {
+	if cfg.BindAddr == "" {
+		return nil, fmt.Errorf("cluster: BindAddr is required")
+	}
+	if cfg.GossipInterval <= 0 {
+		cfg.GossipInterval = defaultGossipInterval
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = defaultDialTimeout
+	}
+	if cfg.NodeID == "" {
+		id, err := randomNodeID()
+		if err != nil {
+			return nil, fmt.Errorf("cluster: generate node ID: %w", err)
+		}
+		cfg.NodeID = id
+	}
+
+	ln, err := net.Listen("tcp", cfg.BindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: listen on %q: %w", cfg.BindAddr, err)
+	}
+
+	g := &Gossip{
+		nodeID:        cfg.NodeID,
+		bindAddr:      cfg.BindAddr,
+		cfg:           cfg,
+		localPublish:  localPublish,
+		listener:      ln,
+		peers:         make(map[string]*peer),
+		conns:         make(map[net.Conn]struct{}),
+		localInterest: make(map[string]struct{}),
+		dedup:         make(map[dedupKey]time.Time),
+		closeCh:       make(chan struct{}),
+	}
+
+	for _, addr := range cfg.Seeds {
+		g.peer(addr)
+	}
+
+	g.wg.Add(3)
+	go g.acceptLoop()
+	go g.gossipLoop()
+	go g.purgeLoop()
+
+	return g, nil
+}
+
+func randomNodeID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// peer returns the outbound peer for addr, creating and starting its
+// writer/dial loop on first use.
+func (g *Gossip) peer(addr string) *peer {
+	g.peersMu.Lock()
+	defer g.peersMu.Unlock()
+
+	if p, ok := g.peers[addr]; ok {
+		return p
+	}
+
+	p := newPeer(addr, g.cfg.DialTimeout)
+	g.peers[addr] = p
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		p.run(g.closeCh)
+	}()
+
+	// Tell the new peer who we are and what we're interested in right
+	// away, instead of waiting for the next gossip tick.
+	p.enqueue(frame{Type: frameHello, NodeID: g.nodeID, Addr: g.bindAddr})
+	p.enqueue(frame{Type: frameInterest, NodeID: g.nodeID, Topics: g.interestSnapshot()})
+
+	return p
+}
+
+// Forward implements ClusterTransport.
+func (g *Gossip) Forward(topicName string, msg models.Message) {
+	if msg.Origin != "" {
+		return
+	}
+	msg.Origin = g.nodeID
+	seq := atomic.AddUint64(&g.seqCounter, 1)
+
+	f := frame{
+		Type:    framePublish,
+		NodeID:  g.nodeID,
+		Topic:   topicName,
+		Message: &msg,
+		Seq:     seq,
+	}
+
+	g.peersMu.Lock()
+	peers := make([]*peer, 0, len(g.peers))
+	for _, p := range g.peers {
+		peers = append(peers, p)
+	}
+	g.peersMu.Unlock()
+
+	for _, p := range peers {
+		if p.interestedIn(topicName) {
+			p.enqueue(f)
+		}
+	}
+}
+
+// UpdateInterest implements ClusterTransport.
+func (g *Gossip) UpdateInterest(topicName string, hasSubscribers bool) {
+	g.localInterestMu.Lock()
+	if hasSubscribers {
+		g.localInterest[topicName] = struct{}{}
+	} else {
+		delete(g.localInterest, topicName)
+	}
+	g.localInterestMu.Unlock()
+}
+
+func (g *Gossip) interestSnapshot() []string {
+	g.localInterestMu.RLock()
+	defer g.localInterestMu.RUnlock()
+
+	topics := make([]string, 0, len(g.localInterest))
+	for t := range g.localInterest {
+		topics = append(topics, t)
+	}
+	return topics
+}
+
+// Close implements ClusterTransport.
+func (g *Gossip) Close() error {
+	g.closeOnce.Do(func() {
+		close(g.closeCh)
+		g.listener.Close()
+
+		// Closing the listener only stops new accepts; connections peers
+		// have already dialed to us stay open (and their readers blocked)
+		// until we close them here too, otherwise Close can deadlock
+		// waiting on a peer that's waiting on us.
+		g.connsMu.Lock()
+		for conn := range g.conns {
+			conn.Close()
+		}
+		g.connsMu.Unlock()
+	})
+	g.wg.Wait()
+	return nil
+}
+
+// acceptLoop accepts inbound connections from peers dialing us and reads
+// frames from each until it closes or this node shuts down.
+func (g *Gossip) acceptLoop() {
+	defer g.wg.Done()
+
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			select {
+			case <-g.closeCh:
+				return
+			default:
+				log.Printf("cluster: accept: %v", err)
+				return
+			}
+		}
+
+		g.connsMu.Lock()
+		g.conns[conn] = struct{}{}
+		g.connsMu.Unlock()
+
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			g.handleInbound(conn)
+		}()
+	}
+}
+
+// handleInbound reads frames from a single inbound connection until it
+// closes, dispatching "hello", "interest", and "publish" frames.
+func (g *Gossip) handleInbound(conn net.Conn) {
+	defer conn.Close()
+	defer func() {
+		g.connsMu.Lock()
+		delete(g.conns, conn)
+		g.connsMu.Unlock()
+	}()
+
+	var fromAddr string
+	for {
+		f, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+
+		switch f.Type {
+		case frameHello:
+			fromAddr = f.Addr
+			if fromAddr != "" && fromAddr != g.bindAddr {
+				g.peer(fromAddr) // make sure we also have an outbound stream to them
+			}
+		case frameInterest:
+			if fromAddr != "" {
+				g.peer(fromAddr).setInterest(f.Topics)
+			}
+		case framePublish:
+			g.handlePublish(f)
+		}
+	}
+}
+
+func (g *Gossip) handlePublish(f frame) {
+	if f.Message == nil {
+		return
+	}
+	if !g.markSeen(f.NodeID, f.Seq) {
+		return // already delivered this (origin, seq) pair
+	}
+	if err := g.localPublish(f.Topic, *f.Message); err != nil {
+		log.Printf("cluster: deliver forwarded publish for topic %q: %v", f.Topic, err)
+	}
+}
+
+// markSeen records (origin, seq) as delivered and reports whether it was
+// new, i.e. whether the caller should actually deliver it.
+func (g *Gossip) markSeen(origin string, seq uint64) bool {
+	key := dedupKey{origin: origin, seq: seq}
+
+	g.dedupMu.Lock()
+	defer g.dedupMu.Unlock()
+
+	if _, dup := g.dedup[key]; dup {
+		return false
+	}
+	g.dedup[key] = time.Now()
+	return true
+}
+
+// gossipLoop periodically pushes this node's topic interest snapshot to
+// every known peer.
+func (g *Gossip) gossipLoop() {
+	defer g.wg.Done()
+
+	ticker := time.NewTicker(g.cfg.GossipInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			topics := g.interestSnapshot()
+
+			g.peersMu.Lock()
+			peers := make([]*peer, 0, len(g.peers))
+			for _, p := range g.peers {
+				peers = append(peers, p)
+			}
+			g.peersMu.Unlock()
+
+			for _, p := range peers {
+				p.enqueue(frame{Type: frameInterest, NodeID: g.nodeID, Topics: topics})
+			}
+		case <-g.closeCh:
+			return
+		}
+	}
+}
+
+// purgeLoop evicts dedup entries older than dedupTTL so the cache doesn't
+// grow without bound on a long-lived node.
+func (g *Gossip) purgeLoop() {
+	defer g.wg.Done()
+
+	ticker := time.NewTicker(dedupTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := time.Now().Add(-dedupTTL)
+			g.dedupMu.Lock()
+			for k, seen := range g.dedup {
+				if seen.Before(cutoff) {
+					delete(g.dedup, k)
+				}
+			}
+			g.dedupMu.Unlock()
+		case <-g.closeCh:
+			return
+		}
+	}
+}
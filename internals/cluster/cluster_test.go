@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+// freeAddr returns an available loopback "host:port" by briefly binding
+// port 0 and releasing it.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("freeAddr: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+// recordingPublisher collects messages handed to it by a Gossip node's
+// localPublish callback, for assertions in tests.
+type recordingPublisher struct {
+	mu   sync.Mutex
+	msgs []models.Message
+}
+
+func (r *recordingPublisher) publish(topicName string, msg models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *recordingPublisher) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+func waitForCount(t *testing.T, r *recordingPublisher, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if r.count() >= want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d forwarded messages, got %d", want, r.count())
+}
+
+func TestGossip_ForwardsToInterestedPeer(t *testing.T) {
+	addrA := freeAddr(t)
+	addrB := freeAddr(t)
+
+	pubA := &recordingPublisher{}
+	pubB := &recordingPublisher{}
+
+	gA, err := New(Config{NodeID: "a", BindAddr: addrA, Seeds: []string{addrB}, GossipInterval: 20 * time.Millisecond}, pubA.publish)
+	if err != nil {
+		t.Fatalf("New gA: %v", err)
+	}
+	defer gA.Close()
+
+	gB, err := New(Config{NodeID: "b", BindAddr: addrB, Seeds: []string{addrA}, GossipInterval: 20 * time.Millisecond}, pubB.publish)
+	if err != nil {
+		t.Fatalf("New gB: %v", err)
+	}
+	defer gB.Close()
+
+	gB.UpdateInterest("orders", true)
+
+	// Give the gossip loop time to push B's interest to A.
+	time.Sleep(100 * time.Millisecond)
+
+	gA.Forward("orders", models.Message{ID: "1", Payload: json.RawMessage(`"hi"`)})
+
+	waitForCount(t, pubB, 1)
+	if pubB.msgs[0].ID != "1" {
+		t.Errorf("expected forwarded message ID 1, got %s", pubB.msgs[0].ID)
+	}
+	if pubB.msgs[0].Origin != "a" {
+		t.Errorf("expected forwarded message origin %q, got %q", "a", pubB.msgs[0].Origin)
+	}
+
+	if pubA.count() != 0 {
+		t.Errorf("expected A not to receive its own forward, got %d messages", pubA.count())
+	}
+}
+
+func TestGossip_DoesNotForwardMessageWithOrigin(t *testing.T) {
+	addrA := freeAddr(t)
+	addrB := freeAddr(t)
+
+	pubB := &recordingPublisher{}
+
+	gA, err := New(Config{NodeID: "a", BindAddr: addrA, Seeds: []string{addrB}, GossipInterval: 20 * time.Millisecond}, func(string, models.Message) error { return nil })
+	if err != nil {
+		t.Fatalf("New gA: %v", err)
+	}
+	defer gA.Close()
+
+	gB, err := New(Config{NodeID: "b", BindAddr: addrB, Seeds: []string{addrA}, GossipInterval: 20 * time.Millisecond}, pubB.publish)
+	if err != nil {
+		t.Fatalf("New gB: %v", err)
+	}
+	defer gB.Close()
+
+	gB.UpdateInterest("orders", true)
+	time.Sleep(100 * time.Millisecond)
+
+	gA.Forward("orders", models.Message{ID: "1", Origin: "somewhere-else"})
+
+	time.Sleep(100 * time.Millisecond)
+	if pubB.count() != 0 {
+		t.Errorf("expected a message with Origin already set not to be forwarded, got %d messages", pubB.count())
+	}
+}
+
+func TestGossip_DedupsRepeatedSeq(t *testing.T) {
+	g := &Gossip{nodeID: "a", dedup: make(map[dedupKey]time.Time)}
+
+	if !g.markSeen("peer-1", 1) {
+		t.Fatal("expected first sighting of (peer-1, 1) to be new")
+	}
+	if g.markSeen("peer-1", 1) {
+		t.Fatal("expected repeated sighting of (peer-1, 1) to be a duplicate")
+	}
+	if !g.markSeen("peer-1", 2) {
+		t.Fatal("expected (peer-1, 2) to be new")
+	}
+}
+
+func TestNoop(t *testing.T) {
+	var n Noop
+	n.Forward("orders", models.Message{ID: "1"})
+	n.UpdateInterest("orders", true)
+	if err := n.Close(); err != nil {
+		t.Errorf("Noop.Close: %v", err)
+	}
+}
+
+func TestWriteReadFrame_RoundTrip(t *testing.T) {
+	var buf netPipeBuf
+	f := frame{Type: framePublish, NodeID: "a", Topic: "orders", Message: &models.Message{ID: "1"}, Seq: 7}
+
+	if err := writeFrame(&buf, f); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+
+	got, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if got.Type != f.Type || got.NodeID != f.NodeID || got.Topic != f.Topic || got.Seq != f.Seq {
+		t.Errorf("round-tripped frame = %+v, want %+v", got, f)
+	}
+	if got.Message == nil || got.Message.ID != "1" {
+		t.Errorf("round-tripped frame message = %+v, want ID 1", got.Message)
+	}
+}
+
+// netPipeBuf is a minimal in-memory io.ReadWriter for frame round-trip tests.
+type netPipeBuf struct {
+	data []byte
+}
+
+func (b *netPipeBuf) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+func (b *netPipeBuf) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, fmt.Errorf("netPipeBuf: no data")
+	}
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+// Frame types exchanged between peers.
+const (
+	// frameHello announces a node's ID and the address it listens on, so
+	// the receiving side can dial it back if it doesn't already have an
+	// outbound stream to it.
+	frameHello = "hello"
+
+	// frameInterest carries a full snapshot of the topics the sender
+	// currently has local subscribers for, replacing any previous
+	// snapshot from that sender.
+	frameInterest = "interest"
+
+	// framePublish carries one forwarded message for a topic, along with
+	// the (node ID, seq) pair the receiver uses to dedup it.
+	framePublish = "publish"
+)
+
+// maxFrameSize bounds a single frame's encoded length, guarding against a
+// corrupt or hostile length prefix forcing an unbounded allocation.
+const maxFrameSize = 16 << 20 // 16 MiB
+
+// frame is the unit exchanged between peers, length-prefixed and
+// JSON-encoded on the wire (see package doc for why JSON instead of
+// protobuf here).
+type frame struct {
+	Type    string          `json:"type"`
+	NodeID  string          `json:"node_id,omitempty"`
+	Addr    string          `json:"addr,omitempty"`
+	Topics  []string        `json:"topics,omitempty"`
+	Topic   string          `json:"topic,omitempty"`
+	Message *models.Message `json:"message,omitempty"`
+	Seq     uint64          `json:"seq,omitempty"`
+}
+
+// writeFrame encodes f as JSON and writes it to w behind a 4-byte
+// big-endian length prefix.
+func writeFrame(w io.Writer, f frame) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("cluster: encode frame: %w", err)
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(b)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// readFrame reads one length-prefixed JSON frame from r.
+func readFrame(r io.Reader) (frame, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return frame{}, fmt.Errorf("cluster: frame of %d bytes exceeds max %d", size, maxFrameSize)
+	}
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return frame{}, err
+	}
+
+	var f frame
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return frame{}, fmt.Errorf("cluster: decode frame: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// peer owns the outbound connection to one mesh member: a dial loop that
+// reconnects on failure, a bounded outbox that gives Forward backpressure
+// without blocking the publisher, and the set of topics that peer has told
+// us it has local subscribers for.
+type peer struct {
+	addr        string
+	dialTimeout time.Duration
+
+	outbox  chan frame
+	dropped uint64 // atomic count of frames dropped because outbox was full
+
+	interestMu sync.RWMutex
+	interest   map[string]struct{}
+}
+
+func newPeer(addr string, dialTimeout time.Duration) *peer {
+	return &peer{
+		addr:        addr,
+		dialTimeout: dialTimeout,
+		outbox:      make(chan frame, defaultOutboxSize),
+		interest:    make(map[string]struct{}),
+	}
+}
+
+// enqueue queues f for delivery to this peer. If the outbox is full (the
+// peer is slow or unreachable), f is dropped rather than blocking the
+// caller, and the drop is counted.
+func (p *peer) enqueue(f frame) {
+	select {
+	case p.outbox <- f:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// DroppedCount returns how many frames have been dropped for this peer
+// because its outbox was full, for metrics and tests.
+func (p *peer) DroppedCount() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+func (p *peer) setInterest(topics []string) {
+	next := make(map[string]struct{}, len(topics))
+	for _, t := range topics {
+		next[t] = struct{}{}
+	}
+
+	p.interestMu.Lock()
+	p.interest = next
+	p.interestMu.Unlock()
+}
+
+func (p *peer) interestedIn(topic string) bool {
+	p.interestMu.RLock()
+	defer p.interestMu.RUnlock()
+
+	_, ok := p.interest[topic]
+	return ok
+}
+
+// run dials addr, draining the outbox onto the connection until it drops
+// or stop is closed, then reconnects after reconnectInterval. It returns
+// once stop is closed.
+func (p *peer) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		conn, err := net.DialTimeout("tcp", p.addr, p.dialTimeout)
+		if err != nil {
+			log.Printf("cluster: dial %s: %v", p.addr, err)
+			if !sleepOrStop(reconnectInterval, stop) {
+				return
+			}
+			continue
+		}
+
+		if !p.drain(conn, stop) {
+			conn.Close()
+			return
+		}
+		conn.Close()
+
+		if !sleepOrStop(reconnectInterval, stop) {
+			return
+		}
+	}
+}
+
+// drain writes frames from the outbox to conn until the connection fails
+// or stop is closed. Returns false if stop was closed.
+func (p *peer) drain(conn net.Conn, stop <-chan struct{}) bool {
+	for {
+		select {
+		case f := <-p.outbox:
+			if err := writeFrame(conn, f); err != nil {
+				log.Printf("cluster: write to %s: %v", p.addr, err)
+				return true // reconnect and keep going
+			}
+		case <-stop:
+			return false
+		}
+	}
+}
+
+func sleepOrStop(d time.Duration, stop <-chan struct{}) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
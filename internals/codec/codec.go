@@ -0,0 +1,111 @@
+// Package codec abstracts the wire format used to marshal outbound
+// models.ServerMsg frames and unmarshal inbound models.WSClientMsg frames,
+// so a WebSocket connection can negotiate something other than JSON (see
+// the Sec-WebSocket-Protocol handling in subscriberService/http) without
+// the delivery path caring which one it got.
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/compress"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType tags the wire format of a Marshal'd frame, so a client that
+// negotiated a non-default Codec knows how to decode what it receives.
+type ContentType byte
+
+const (
+	// ContentTypeJSON marks a frame encoded as plain JSON.
+	ContentTypeJSON ContentType = 0
+	// ContentTypeMsgpack marks a frame encoded as MessagePack.
+	ContentTypeMsgpack ContentType = 1
+)
+
+// compressedFlag is set on the content-type byte returned by
+// CompressedCodec to signal that the remaining bytes must be decompressed
+// before they match the inner codec's format.
+const compressedFlag byte = 0x80
+
+// Codec marshals a ServerMsg for the wire and unmarshals a WSClientMsg off
+// it. Every Codec in this package is stateless and safe for concurrent use.
+type Codec interface {
+	// Marshal encodes msg, returning the wire bytes and the content-type
+	// byte that identifies how they were encoded.
+	Marshal(msg models.ServerMsg) ([]byte, byte, error)
+
+	// Unmarshal decodes data, previously produced by the matching client
+	// side of this Codec, into a WSClientMsg.
+	Unmarshal(data []byte) (models.WSClientMsg, error)
+}
+
+// JSON is the default Codec, matching the plain-JSON wire format used
+// before codec negotiation existed.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(msg models.ServerMsg) ([]byte, byte, error) {
+	b, err := json.Marshal(msg)
+	return b, byte(ContentTypeJSON), err
+}
+
+func (jsonCodec) Unmarshal(data []byte) (models.WSClientMsg, error) {
+	var msg models.WSClientMsg
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// Msgpack encodes frames with MessagePack instead of JSON, trading
+// human-readability for a smaller, faster-to-parse wire format.
+var Msgpack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(msg models.ServerMsg) ([]byte, byte, error) {
+	b, err := msgpack.Marshal(msg)
+	return b, byte(ContentTypeMsgpack), err
+}
+
+func (msgpackCodec) Unmarshal(data []byte) (models.WSClientMsg, error) {
+	var msg models.WSClientMsg
+	err := msgpack.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// CompressedCodec wraps another Codec, compressing its marshaled output
+// with Enc (see internals/compress) once it reaches MinSize bytes. The
+// content-type byte it returns is the inner codec's with bit 7 set, so a
+// decoder can tell compressed frames apart from plain ones of the same
+// inner format.
+type CompressedCodec struct {
+	Inner   Codec
+	Enc     compress.Encoding
+	MinSize int
+}
+
+func (c CompressedCodec) Marshal(msg models.ServerMsg) ([]byte, byte, error) {
+	payload, contentType, err := c.Inner.Marshal(msg)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.Enc == "" || c.Enc == compress.EncodingIdentity || len(payload) < c.MinSize {
+		return payload, contentType, nil
+	}
+
+	compressed, err := compress.Compress(c.Enc, payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("codec: compress: %w", err)
+	}
+	return compressed, contentType | compressedFlag, nil
+}
+
+// Unmarshal decodes data with the inner codec. Inbound client frames
+// (subscribe/ack/publish, ...) are small control messages and are never
+// sent compressed, so there is nothing to undo here.
+func (c CompressedCodec) Unmarshal(data []byte) (models.WSClientMsg, error) {
+	return c.Inner.Unmarshal(data)
+}
@@ -0,0 +1,80 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/compress"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	msg := models.ServerMsg{Type: "message", Topic: "orders"}
+
+	payload, contentType, err := JSON.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if contentType != byte(ContentTypeJSON) {
+		t.Errorf("expected ContentTypeJSON, got %d", contentType)
+	}
+
+	if _, err := JSON.Unmarshal(payload); err != nil {
+		t.Errorf("Unmarshal of JSON's own output failed: %v", err)
+	}
+}
+
+func TestMsgpack_RoundTrip(t *testing.T) {
+	req := models.WSClientMsg{Type: "subscribe", Topic: "orders"}
+	encoded, err := msgpack.Marshal(req)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	decoded, err := Msgpack.Unmarshal(encoded)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Type != req.Type || decoded.Topic != req.Topic {
+		t.Errorf("round-trip mismatch: got %+v, want %+v", decoded, req)
+	}
+
+	msg := models.ServerMsg{Type: "message", Topic: "orders"}
+	payload, contentType, err := Msgpack.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if contentType != byte(ContentTypeMsgpack) {
+		t.Errorf("expected ContentTypeMsgpack, got %d", contentType)
+	}
+	if len(payload) == 0 {
+		t.Error("Marshal returned empty output")
+	}
+}
+
+func TestCompressedCodec_CompressesAboveThreshold(t *testing.T) {
+	big := append([]byte(`"`), make([]byte, 200)...)
+	for i := 1; i < len(big); i++ {
+		big[i] = 'x'
+	}
+	big = append(big, '"')
+	msg := models.ServerMsg{
+		Type:    "message",
+		Topic:   "orders",
+		Message: &models.Message{ID: "m1", Payload: big},
+	}
+
+	c := CompressedCodec{Inner: JSON, Enc: compress.EncodingGzip, MinSize: 64}
+	if _, contentType, err := c.Marshal(msg); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	} else if contentType&compressedFlag == 0 {
+		t.Error("expected compressed flag to be set for a payload over MinSize")
+	}
+
+	small := CompressedCodec{Inner: JSON, Enc: compress.EncodingGzip, MinSize: 1 << 20}
+	if _, contentType, err := small.Marshal(models.ServerMsg{Type: "ping"}); err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	} else if contentType&compressedFlag != 0 {
+		t.Error("expected compressed flag to be unset for a payload under MinSize")
+	}
+}
@@ -0,0 +1,103 @@
+// Package compress provides negotiated payload compression for the
+// WebSocket subscriber transport and codec.CompressedCodec, so the chosen
+// encoding and its wire format stay consistent between them. The SSE
+// endpoint does not use it and writes uncompressed JSON.
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Encoding identifies a negotiated compression scheme.
+type Encoding string
+
+const (
+	// EncodingIdentity means no compression is applied.
+	EncodingIdentity Encoding = "identity"
+	// EncodingGzip compresses with gzip.
+	EncodingGzip Encoding = "gzip"
+	// EncodingDeflate compresses with raw DEFLATE.
+	EncodingDeflate Encoding = "deflate"
+	// EncodingBrotli compresses with brotli.
+	EncodingBrotli Encoding = "br"
+)
+
+// supportedInPreferenceOrder lists the encodings Negotiate will pick among,
+// most preferred first.
+var supportedInPreferenceOrder = []Encoding{EncodingBrotli, EncodingGzip, EncodingDeflate}
+
+// Negotiate parses a comma-separated Accept-Encoding-style value (q-values
+// are ignored) and returns the most preferred encoding inmem-pubsub
+// supports, or EncodingIdentity if none match or the header is empty.
+func Negotiate(acceptEncoding string) Encoding {
+	if acceptEncoding == "" {
+		return EncodingIdentity
+	}
+
+	offered := make(map[Encoding]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		offered[Encoding(strings.ToLower(name))] = true
+	}
+
+	for _, enc := range supportedInPreferenceOrder {
+		if offered[enc] {
+			return enc
+		}
+	}
+	return EncodingIdentity
+}
+
+// Compress encodes payload with enc. EncodingIdentity (and the empty
+// string) return payload unchanged.
+func Compress(enc Encoding, payload []byte) ([]byte, error) {
+	switch enc {
+	case EncodingIdentity, "":
+		return payload, nil
+
+	case EncodingGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("compress: gzip write: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("compress: gzip close: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	case EncodingDeflate:
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, fmt.Errorf("compress: flate writer: %w", err)
+		}
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("compress: flate write: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("compress: flate close: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	case EncodingBrotli:
+		var buf bytes.Buffer
+		w := brotli.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, fmt.Errorf("compress: brotli write: %w", err)
+		}
+		if err := w.Close(); err != nil {
+			return nil, fmt.Errorf("compress: brotli close: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	default:
+		return nil, fmt.Errorf("compress: unsupported encoding %q", enc)
+	}
+}
@@ -0,0 +1,50 @@
+package compress
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		want   Encoding
+	}{
+		{"", EncodingIdentity},
+		{"gzip", EncodingGzip},
+		{"deflate", EncodingDeflate},
+		{"br", EncodingBrotli},
+		{"gzip, br;q=0.9", EncodingBrotli},
+		{"identity", EncodingIdentity},
+		{"sdch", EncodingIdentity},
+	}
+
+	for _, tc := range cases {
+		if got := Negotiate(tc.header); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestCompress_RoundTripsThroughAllEncodings(t *testing.T) {
+	payload := bytes.Repeat([]byte("hello inmem-pubsub "), 20)
+
+	for _, enc := range []Encoding{EncodingIdentity, EncodingGzip, EncodingDeflate, EncodingBrotli} {
+		out, err := Compress(enc, payload)
+		if err != nil {
+			t.Fatalf("Compress(%q) failed: %v", enc, err)
+		}
+		if enc == EncodingIdentity && !bytes.Equal(out, payload) {
+			t.Errorf("identity encoding should not modify the payload")
+		}
+		if len(out) == 0 {
+			t.Errorf("Compress(%q) returned empty output", enc)
+		}
+	}
+}
+
+func TestCompress_UnsupportedEncoding(t *testing.T) {
+	if _, err := Compress("snappy", []byte("x")); err == nil {
+		t.Error("expected an error for an unsupported encoding")
+	}
+}
@@ -20,26 +20,172 @@ type Config struct {
 	DefaultWSBufferSize   int
 	DefaultPublishPolicy  string
 
+	// WALDir is the base directory under which each topic gets a
+	// per-topic WAL directory for durable ring buffer history. An empty
+	// value disables WAL persistence and topics use plain in-memory ring
+	// buffers.
+	WALDir string
+
+	// WALFsyncPolicy is one of "always", "interval", or "never" (the
+	// default), controlling how aggressively each topic's WAL flushes
+	// appended records to stable storage. See ringbuffer.FsyncPolicy.
+	WALFsyncPolicy string
+
+	// WALFsyncInterval is the flush period used when WALFsyncPolicy is
+	// "interval".
+	WALFsyncInterval time.Duration
+
+	// WALRetention, if positive, prunes whole WAL segments older than this
+	// window when a topic's WAL is opened (including on restart). Zero
+	// disables pruning and keeps the full history.
+	WALRetention time.Duration
+
+	// WALMaxTotalBytes, if positive, bounds the total on-disk size of a
+	// topic's WAL: a background compactor deletes the oldest segments
+	// (never the one currently being appended to) until the total falls
+	// back under this limit. Zero disables size-based pruning.
+	WALMaxTotalBytes int64
+
+	// TopicReapInterval controls how often the topic manager's background
+	// reaper scans for idle, TTL-expired topics to delete.
+	TopicReapInterval time.Duration
+
+	// DefaultTopicTTL is the idle-expiry duration applied to topics
+	// created without an explicit TTL (e.g. registry.CreateTopic). Zero,
+	// the default, means such topics are never reaped for being idle.
+	DefaultTopicTTL time.Duration
+
+	// DefaultBlockTimeout is how long a publish blocks a subscriber with a
+	// full buffer before giving up, when DefaultPublishPolicy is "BLOCK".
+	DefaultBlockTimeout time.Duration
+
+	// DefaultAckTimeout is how long an unacknowledged message waits before
+	// being considered for redelivery, when DefaultPublishPolicy is "ACK".
+	DefaultAckTimeout time.Duration
+
+	// DefaultMaxDeliveryAttempts caps how many times an unacknowledged
+	// message is redelivered before being dead-lettered, when
+	// DefaultPublishPolicy is "ACK".
+	DefaultMaxDeliveryAttempts int
+
+	// AckReapInterval controls how often the topic manager's background
+	// reaper scans for AckMode messages past their ack deadline.
+	AckReapInterval time.Duration
+
 	// Timeout configuration
 	WriteTimeout time.Duration
 	ReadTimeout  time.Duration
 
+	// PingPeriod is how often a subscriber's WebSocket keepalive sends a
+	// ping frame (see subscriber.Subscriber.SetKeepalive). It should stay
+	// under PongWait.
+	PingPeriod time.Duration
+
+	// PongWait is how long a subscriber's connection may go without a pong
+	// before its keepalive gives up on it.
+	PongWait time.Duration
+
+	// WriteWait bounds how long a single keepalive ping write may take
+	// before it is treated as a failed write.
+	WriteWait time.Duration
+
 	// Logging configuration
 	LogLevel string
+
+	// MetricsBackend selects the metrics.Registry implementation wired into
+	// topics and subscriber connections: "none" (the default, a no-op) or
+	// "prometheus".
+	MetricsBackend string
+
+	// DefaultCompression is the payload encoding a WebSocket connection
+	// starts with when it negotiates no Sec-WebSocket-Protocol codec and
+	// sends no non-identity ?encoding= query param: "identity" (the
+	// default), "gzip", "deflate", or "br". A subscribe-time Compression
+	// field replaces it only while it is "identity"; a non-identity
+	// default is kept.
+	DefaultCompression string
+
+	// ClusterBindAddr is the "host:port" this node listens on for peer
+	// connections. Empty (the default) disables cluster mode, so
+	// topicManagerService uses cluster.Noop.
+	ClusterBindAddr string
+
+	// ClusterSeeds is a comma-separated list of peer "host:port"
+	// addresses to dial on startup.
+	ClusterSeeds string
+
+	// ClusterNodeID uniquely identifies this node to its peers. A random
+	// one is generated if empty.
+	ClusterNodeID string
+
+	// ClusterGossipInterval controls how often this node pushes its topic
+	// interest snapshot to its peers.
+	ClusterGossipInterval time.Duration
+
+	// MaxBatchMessages caps how many messages a topic's publish Bundler
+	// (see registry.Registry.PublishMessageAsync) accumulates before
+	// flushing them as a single topic.Topic.PublishBatch call.
+	MaxBatchMessages int
+
+	// MaxBatchBytes, if positive, flushes a Bundler's batch early once the
+	// summed length of its messages' encoded payloads reaches this many
+	// bytes, even if MaxBatchMessages hasn't been reached yet. Zero
+	// disables the byte trigger.
+	MaxBatchBytes int
+
+	// MaxBatchDelay bounds how long a Bundler holds a partial batch before
+	// flushing it anyway, so a topic with light traffic doesn't stall
+	// waiting to fill MaxBatchMessages.
+	MaxBatchDelay time.Duration
+
+	// MaxMessageBytes, if positive, rejects a PublishMessageAsync call
+	// whose message payload exceeds it with registry.ErrOversizedMessage
+	// before it ever reaches a Bundler. Zero disables the check.
+	MaxMessageBytes int
+
+	// TopicShards is the number of shards the registry's topic lookup
+	// splits across, rounded up to the next power of two. Zero, the
+	// default, substitutes registry's own default shard count.
+	TopicShards int
 }
 
 // NewConfig creates a new configuration with default values.
 func NewConfig() *Config {
 	return &Config{
-		Port:                  getEnv("PORT", "8080"),
-		Host:                  getEnv("HOST", "0.0.0.0"),
-		WSPath:                getEnv("WS_PATH", "/ws"),
-		DefaultRingBufferSize: getEnvAsInt("DEFAULT_RING_BUFFER_SIZE", 1000),
-		DefaultWSBufferSize:   getEnvAsInt("DEFAULT_WS_BUFFER_SIZE", 100),
-		DefaultPublishPolicy:  getEnv("DEFAULT_PUBLISH_POLICY", "DROP_OLDEST"),
-		WriteTimeout:          getEnvAsDuration("WRITE_TIMEOUT", 30*time.Second),
-		ReadTimeout:           getEnvAsDuration("READ_TIMEOUT", 60*time.Second),
-		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		Port:                       getEnv("PORT", "8080"),
+		Host:                       getEnv("HOST", "0.0.0.0"),
+		WSPath:                     getEnv("WS_PATH", "/ws"),
+		DefaultRingBufferSize:      getEnvAsInt("DEFAULT_RING_BUFFER_SIZE", 1000),
+		DefaultWSBufferSize:        getEnvAsInt("DEFAULT_WS_BUFFER_SIZE", 100),
+		DefaultPublishPolicy:       getEnv("DEFAULT_PUBLISH_POLICY", "DROP_OLDEST"),
+		WALDir:                     getEnv("WAL_DIR", ""),
+		WALFsyncPolicy:             getEnv("WAL_FSYNC_POLICY", "never"),
+		WALFsyncInterval:           getEnvAsDuration("WAL_FSYNC_INTERVAL", time.Second),
+		WALRetention:               getEnvAsDuration("WAL_RETENTION", 0),
+		WALMaxTotalBytes:           getEnvAsInt64("WAL_MAX_TOTAL_BYTES", 0),
+		TopicReapInterval:          getEnvAsDuration("TOPIC_REAP_INTERVAL", time.Minute),
+		DefaultTopicTTL:            getEnvAsDuration("DEFAULT_TOPIC_TTL", 0),
+		DefaultBlockTimeout:        getEnvAsDuration("DEFAULT_BLOCK_TIMEOUT", 5*time.Second),
+		DefaultAckTimeout:          getEnvAsDuration("DEFAULT_ACK_TIMEOUT", 30*time.Second),
+		DefaultMaxDeliveryAttempts: getEnvAsInt("DEFAULT_MAX_DELIVERY_ATTEMPTS", 5),
+		AckReapInterval:            getEnvAsDuration("ACK_REAP_INTERVAL", 5*time.Second),
+		WriteTimeout:               getEnvAsDuration("WRITE_TIMEOUT", 30*time.Second),
+		ReadTimeout:                getEnvAsDuration("READ_TIMEOUT", 60*time.Second),
+		PingPeriod:                 getEnvAsDuration("PING_PERIOD", 54*time.Second),
+		PongWait:                   getEnvAsDuration("PONG_WAIT", 60*time.Second),
+		WriteWait:                  getEnvAsDuration("WRITE_WAIT", 10*time.Second),
+		LogLevel:                   getEnv("LOG_LEVEL", "info"),
+		MetricsBackend:             getEnv("METRICS_BACKEND", "none"),
+		DefaultCompression:         getEnv("DEFAULT_COMPRESSION", "identity"),
+		ClusterBindAddr:            getEnv("CLUSTER_BIND_ADDR", ""),
+		ClusterSeeds:               getEnv("CLUSTER_SEEDS", ""),
+		ClusterNodeID:              getEnv("CLUSTER_NODE_ID", ""),
+		ClusterGossipInterval:      getEnvAsDuration("CLUSTER_GOSSIP_INTERVAL", 5*time.Second),
+		MaxBatchMessages:           getEnvAsInt("MAX_BATCH_MESSAGES", 100),
+		MaxBatchBytes:              getEnvAsInt("MAX_BATCH_BYTES", 1<<20),
+		MaxBatchDelay:              getEnvAsDuration("MAX_BATCH_DELAY", 10*time.Millisecond),
+		MaxMessageBytes:            getEnvAsInt("MAX_MESSAGE_BYTES", 10<<20),
+		TopicShards:                getEnvAsInt("TOPIC_SHARDS", 0),
 	}
 }
 
@@ -50,10 +196,35 @@ func (c *Config) ParseFlags() {
 	flag.StringVar(&c.WSPath, "ws-path", c.WSPath, "WebSocket endpoint path")
 	flag.IntVar(&c.DefaultRingBufferSize, "ring-buffer-size", c.DefaultRingBufferSize, "Default ring buffer size for topics")
 	flag.IntVar(&c.DefaultWSBufferSize, "ws-buffer-size", c.DefaultWSBufferSize, "Default WebSocket buffer size")
-	flag.StringVar(&c.DefaultPublishPolicy, "publish-policy", c.DefaultPublishPolicy, "Default publish policy (DROP_OLDEST, DISCONNECT)")
+	flag.StringVar(&c.DefaultPublishPolicy, "publish-policy", c.DefaultPublishPolicy, "Default publish policy (DROP_OLDEST, DROP_NEWEST, BLOCK, DISCONNECT, ACK)")
+	flag.StringVar(&c.WALDir, "wal-dir", c.WALDir, "Base directory for per-topic WAL persistence (empty disables WAL)")
+	flag.StringVar(&c.WALFsyncPolicy, "wal-fsync-policy", c.WALFsyncPolicy, "WAL fsync policy: always, interval, or never")
+	flag.DurationVar(&c.WALFsyncInterval, "wal-fsync-interval", c.WALFsyncInterval, "Flush period when -wal-fsync-policy=interval")
+	flag.DurationVar(&c.WALRetention, "wal-retention", c.WALRetention, "Prune WAL segments older than this window on open (0 disables pruning)")
+	flag.Int64Var(&c.WALMaxTotalBytes, "wal-max-total-bytes", c.WALMaxTotalBytes, "Bound a topic's total WAL size on disk, pruning oldest segments in the background (0 disables)")
+	flag.DurationVar(&c.TopicReapInterval, "topic-reap-interval", c.TopicReapInterval, "How often to scan for idle, TTL-expired topics")
+	flag.DurationVar(&c.DefaultTopicTTL, "default-topic-ttl", c.DefaultTopicTTL, "Idle-expiry duration applied to topics created without an explicit TTL (0 disables)")
+	flag.DurationVar(&c.DefaultBlockTimeout, "block-timeout", c.DefaultBlockTimeout, "Publish block timeout when -publish-policy=BLOCK")
+	flag.DurationVar(&c.DefaultAckTimeout, "ack-timeout", c.DefaultAckTimeout, "Redelivery timeout when -publish-policy=ACK")
+	flag.IntVar(&c.DefaultMaxDeliveryAttempts, "max-delivery-attempts", c.DefaultMaxDeliveryAttempts, "Max redeliveries before dead-lettering when -publish-policy=ACK")
+	flag.DurationVar(&c.AckReapInterval, "ack-reap-interval", c.AckReapInterval, "How often to scan for unacknowledged ACK-mode messages")
 	flag.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "WebSocket write timeout")
 	flag.DurationVar(&c.ReadTimeout, "read-timeout", c.ReadTimeout, "WebSocket read timeout")
+	flag.DurationVar(&c.PingPeriod, "ping-period", c.PingPeriod, "How often a subscriber's keepalive sends a ping frame")
+	flag.DurationVar(&c.PongWait, "pong-wait", c.PongWait, "How long a subscriber's connection may go without a pong before its keepalive gives up on it")
+	flag.DurationVar(&c.WriteWait, "write-wait", c.WriteWait, "How long a single keepalive ping write may take before it is treated as a failed write")
 	flag.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Log level (debug, info, warn, error)")
+	flag.StringVar(&c.MetricsBackend, "metrics-backend", c.MetricsBackend, "Metrics backend: none or prometheus")
+	flag.StringVar(&c.DefaultCompression, "default-compression", c.DefaultCompression, "Default payload encoding for a WebSocket connection that doesn't negotiate one itself: identity, gzip, deflate, or br")
+	flag.StringVar(&c.ClusterBindAddr, "cluster-bind-addr", c.ClusterBindAddr, "host:port to listen on for peer connections (empty disables cluster mode)")
+	flag.StringVar(&c.ClusterSeeds, "cluster-seeds", c.ClusterSeeds, "Comma-separated host:port list of peers to dial on startup")
+	flag.StringVar(&c.ClusterNodeID, "cluster-node-id", c.ClusterNodeID, "This node's cluster ID (random if empty)")
+	flag.DurationVar(&c.ClusterGossipInterval, "cluster-gossip-interval", c.ClusterGossipInterval, "How often to push this node's topic interest to its peers")
+	flag.IntVar(&c.MaxBatchMessages, "max-batch-messages", c.MaxBatchMessages, "Max messages a publish Bundler accumulates before flushing")
+	flag.IntVar(&c.MaxBatchBytes, "max-batch-bytes", c.MaxBatchBytes, "Max summed payload bytes a publish Bundler accumulates before flushing early (0 disables)")
+	flag.DurationVar(&c.MaxBatchDelay, "max-batch-delay", c.MaxBatchDelay, "Max time a publish Bundler holds a partial batch before flushing it anyway")
+	flag.IntVar(&c.MaxMessageBytes, "max-message-bytes", c.MaxMessageBytes, "Max payload bytes PublishMessageAsync accepts before rejecting with ErrOversizedMessage (0 disables)")
+	flag.IntVar(&c.TopicShards, "topic-shards", c.TopicShards, "Number of shards the registry's topic lookup splits across, rounded up to a power of two (0 uses the registry default)")
 
 	flag.Parse()
 }
@@ -76,6 +247,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsInt64 gets an environment variable as an int64 or returns a default value.
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsDuration gets an environment variable as a duration or returns a default value.
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,221 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// evalCtx carries a single message's fields available to a predicate: its
+// string attributes, its JSON payload decoded into plain Go values
+// (map[string]any, []any, float64, string, bool, nil), and the topic/time
+// it's being evaluated against (see Context).
+type evalCtx struct {
+	attrs   map[string]string
+	payload any
+	topic   string
+	ts      time.Time
+}
+
+// decodePayload unmarshals raw into a generic JSON value for path lookups.
+// An empty or invalid-JSON payload decodes to nil, so payload.* lookups
+// against it simply report "not found" rather than erroring.
+func decodePayload(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// node is one AST node in a compiled filter expression.
+type node interface {
+	eval(ctx evalCtx) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(ctx evalCtx) bool { return n.left.eval(ctx) && n.right.eval(ctx) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(ctx evalCtx) bool { return n.left.eval(ctx) || n.right.eval(ctx) }
+
+type notNode struct{ child node }
+
+func (n notNode) eval(ctx evalCtx) bool { return !n.child.eval(ctx) }
+
+// operandKind distinguishes the part of a message an operand reads from.
+type operandKind int
+
+const (
+	opAttr operandKind = iota
+	opPayload
+	opTopic
+	opTs
+)
+
+// operand identifies a field a comparison reads from a message: an
+// attribute (attr.key), a JSON payload path (payload.foo.bar), or the bare
+// pseudo-fields topic and ts naming the topic it was published to and its
+// publish time (as a Unix-seconds number).
+type operand struct {
+	kind operandKind
+	key  string   // attribute name, when kind == opAttr
+	path []string // payload field path, when kind == opPayload
+}
+
+// lookup resolves the operand against ctx, returning the value and whether
+// it was present at all.
+func (o operand) lookup(ctx evalCtx) (any, bool) {
+	switch o.kind {
+	case opAttr:
+		v, ok := ctx.attrs[o.key]
+		return v, ok
+
+	case opTopic:
+		return ctx.topic, true
+
+	case opTs:
+		return float64(ctx.ts.Unix()), true
+
+	default: // opPayload
+		cur := ctx.payload
+		for _, segment := range o.path {
+			m, ok := cur.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			cur, ok = m[segment]
+			if !ok {
+				return nil, false
+			}
+		}
+		return cur, true
+	}
+}
+
+type hasNode struct{ op operand }
+
+func (n hasNode) eval(ctx evalCtx) bool {
+	_, ok := n.op.lookup(ctx)
+	return ok
+}
+
+// compareNode implements ==, !=, >, >=, <, <= between an operand's value and
+// a literal.
+type compareNode struct {
+	op      operand
+	cmpOp   tokenKind
+	literal any
+}
+
+func (n compareNode) eval(ctx evalCtx) bool {
+	v, ok := n.op.lookup(ctx)
+	if !ok {
+		return false
+	}
+
+	switch n.cmpOp {
+	case tokEq:
+		return valuesEqual(v, n.literal)
+	case tokNe:
+		return !valuesEqual(v, n.literal)
+	case tokGt, tokGe, tokLt, tokLe:
+		a, aok := asFloat(v)
+		b, bok := asFloat(n.literal)
+		if !aok || !bok {
+			return false
+		}
+		switch n.cmpOp {
+		case tokGt:
+			return a > b
+		case tokGe:
+			return a >= b
+		case tokLt:
+			return a < b
+		case tokLe:
+			return a <= b
+		}
+	}
+	return false
+}
+
+// inNode implements the IN (...) membership test.
+type inNode struct {
+	op       operand
+	literals []any
+}
+
+func (n inNode) eval(ctx evalCtx) bool {
+	v, ok := n.op.lookup(ctx)
+	if !ok {
+		return false
+	}
+	for _, lit := range n.literals {
+		if valuesEqual(v, lit) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsNode implements CONTAINS: substring search against a string
+// operand, or membership search against a []any operand (e.g. a JSON array
+// payload field).
+type containsNode struct {
+	op      operand
+	literal any
+}
+
+func (n containsNode) eval(ctx evalCtx) bool {
+	v, ok := n.op.lookup(ctx)
+	if !ok {
+		return false
+	}
+
+	switch val := v.(type) {
+	case string:
+		s, ok := n.literal.(string)
+		return ok && strings.Contains(val, s)
+	case []any:
+		for _, item := range val {
+			if valuesEqual(item, n.literal) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func valuesEqual(a, b any) bool {
+	if af, aok := asFloat(a); aok {
+		if bf, bok := asFloat(b); bok {
+			return af == bf
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// asFloat coerces v to a float64 for numeric comparisons. Attribute values
+// are always strings (models.Message.Attributes is map[string]string), so a
+// numeric literal like `attr.priority > 3` still works by parsing the
+// string.
+func asFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,98 @@
+// Package filter implements a small predicate language for subscriber-side
+// message filtering, so a subscriber only receives messages matching an
+// expression it supplied at subscribe time (mirroring Google Pub/Sub
+// attribute filtering) instead of every message published to a topic.
+//
+// Expressions compare a message's attributes (models.Message.Attributes),
+// JSON payload fields, or the topic/time it's being published against
+// literals, combined with AND/OR/NOT:
+//
+//	attr.type == "order"
+//	attr.region IN ("us", "eu")
+//	has(attr.priority)
+//	EXISTS(payload.total)
+//	payload.tags CONTAINS "urgent"
+//	topic == "orders.created" AND payload.total > 10 AND NOT has(attr.test)
+//
+// An expression is parsed once, at subscribe time, into a Predicate; that
+// Predicate is then cheaply re-evaluated against every message published to
+// the topic.
+package filter
+
+import (
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+// Predicate is a parsed filter expression ready to be evaluated against
+// messages. The zero value is not usable; construct one with Compile.
+type Predicate struct {
+	root node
+}
+
+// Compile parses expr into a Predicate. Returns an error if expr is not a
+// syntactically valid filter expression.
+func Compile(expr string) (*Predicate, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected token %q", p.peek().text)
+	}
+
+	return &Predicate{root: root}, nil
+}
+
+// Matches reports whether msg satisfies the predicate. A msg.Payload that
+// isn't a JSON object is treated as having no payload fields, so a
+// payload.* comparison against it simply doesn't match. Its topic and ts
+// pseudo-fields are left empty; use MatchesContext when those matter.
+//
+// Evaluating the same message against several subscribers' predicates is
+// better done with a single Context built once via NewContext, so the
+// payload is decoded once rather than once per predicate; see
+// MatchesContext.
+func (p *Predicate) Matches(msg models.Message) bool {
+	return p.MatchesContext(NewContext(msg, "", time.Time{}))
+}
+
+// Context is a message's fields pre-decoded for filter evaluation: its
+// attributes, its JSON payload decoded once into plain Go values, and the
+// topic/time it's being published against. Build one with NewContext and
+// reuse it across every subscriber's Predicate for a given publish.
+type Context struct {
+	attrs   map[string]string
+	payload any
+	topic   string
+	ts      time.Time
+}
+
+// NewContext decodes msg's payload once and attaches topic and ts so the
+// result can be shared across many Predicate.MatchesContext calls for the
+// same message, e.g. one per subscriber in topic.Topic.Publish.
+func NewContext(msg models.Message, topic string, ts time.Time) Context {
+	return Context{
+		attrs:   msg.Attributes,
+		payload: decodePayload(msg.Payload),
+		topic:   topic,
+		ts:      ts,
+	}
+}
+
+// MatchesContext reports whether ctx satisfies the predicate.
+func (p *Predicate) MatchesContext(ctx Context) bool {
+	return p.root.eval(evalCtx{
+		attrs:   ctx.attrs,
+		payload: ctx.payload,
+		topic:   ctx.topic,
+		ts:      ctx.ts,
+	})
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+func TestPredicate_Matches(t *testing.T) {
+	msg := models.Message{
+		Attributes: map[string]string{"type": "order", "priority": "5"},
+		Payload:    json.RawMessage(`{"total": 42, "region": "us", "tags": ["a", "b"]}`),
+	}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{`attr.type == "order"`, true},
+		{`attr.type == "invoice"`, false},
+		{`attr.type != "invoice"`, true},
+		{`attr.region IN ("us", "eu")`, false}, // region isn't an attribute, only a payload field
+		{`payload.region IN ("us", "eu")`, true},
+		{`has(attr.priority)`, true},
+		{`has(attr.missing)`, false},
+		{`payload.total > 10`, true},
+		{`payload.total > 100`, false},
+		{`payload.total >= 42`, true},
+		{`payload.total <= 42`, true},
+		{`payload.total < 42`, false},
+		{`attr.priority > 3`, true},
+		{`attr.type == "order" AND payload.total > 10`, true},
+		{`attr.type == "invoice" OR payload.total > 10`, true},
+		{`NOT attr.type == "invoice"`, true},
+		{`attr.type == "order" AND NOT has(attr.missing)`, true},
+		{`(attr.type == "invoice" OR attr.type == "order") AND payload.total > 10`, true},
+		{`has(payload.total)`, true},
+		{`has(payload.missing)`, false},
+		{`EXISTS(attr.priority)`, true},
+		{`EXISTS(attr.missing)`, false},
+		{`payload.region CONTAINS "u"`, true},
+		{`payload.region CONTAINS "z"`, false},
+		{`payload.tags CONTAINS "a"`, true},
+		{`payload.tags CONTAINS "z"`, false},
+	}
+
+	for _, tc := range cases {
+		pred, err := Compile(tc.expr)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", tc.expr, err)
+		}
+		if got := pred.Matches(msg); got != tc.want {
+			t.Errorf("Compile(%q).Matches(msg) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestPredicate_Matches_NoPayload(t *testing.T) {
+	msg := models.Message{Attributes: map[string]string{"type": "order"}}
+
+	pred, err := Compile(`attr.type == "order" AND NOT has(payload.total)`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if !pred.Matches(msg) {
+		t.Error("expected predicate to match a message with no payload")
+	}
+}
+
+func TestPredicate_MatchesContext_Topic(t *testing.T) {
+	msg := models.Message{Attributes: map[string]string{"type": "order"}}
+
+	pred, err := Compile(`topic == "orders.created" AND attr.type == "order"`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	ctx := NewContext(msg, "orders.created", time.Now())
+	if !pred.MatchesContext(ctx) {
+		t.Error("expected predicate to match the shared context's topic")
+	}
+
+	other := NewContext(msg, "orders.cancelled", time.Now())
+	if pred.MatchesContext(other) {
+		t.Error("expected predicate not to match a different topic")
+	}
+}
+
+func TestCompile_InvalidExpressions(t *testing.T) {
+	cases := []string{
+		``,
+		`attr.type ==`,
+		`attr.type == "order" AND`,
+		`(attr.type == "order"`,
+		`foo.bar == "x"`,
+		`attr.type = "order"`,
+		`has(attr.type`,
+		`attr.type IN "order"`,
+	}
+
+	for _, expr := range cases {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Compile(%q): expected an error, got nil", expr)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokAnd
+	tokOr
+	tokNot
+	tokIn
+	tokHas
+	tokContains
+	tokEq
+	tokNe
+	tokGt
+	tokGe
+	tokLt
+	tokLe
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+}
+
+// keywords are matched case-sensitively exactly as written here: the
+// boolean operators are conventionally upper-cased, while has() reads like
+// a function call and is lowercase. EXISTS is accepted as an upper-cased
+// alias of has() for callers coming from SQL-flavored query languages.
+var keywords = map[string]tokenKind{
+	"AND":      tokAnd,
+	"OR":       tokOr,
+	"NOT":      tokNot,
+	"IN":       tokIn,
+	"has":      tokHas,
+	"EXISTS":   tokHas,
+	"CONTAINS": tokContains,
+}
+
+// lex tokenizes a filter expression. Identifiers are runs of letters,
+// digits, underscores, and dots, so dotted paths like attr.key or
+// payload.foo.bar lex as a single token.
+func lex(expr string) ([]token, error) {
+	var toks []token
+	r := []rune(expr)
+	i := 0
+
+	for i < len(r) {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, text: "("})
+			i++
+
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")"})
+			i++
+
+		case c == ',':
+			toks = append(toks, token{kind: tokComma, text: ","})
+			i++
+
+		case c == '=' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{kind: tokEq, text: "=="})
+			i += 2
+
+		case c == '!' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{kind: tokNe, text: "!="})
+			i += 2
+
+		case c == '>' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{kind: tokGe, text: ">="})
+			i += 2
+
+		case c == '<' && i+1 < len(r) && r[i+1] == '=':
+			toks = append(toks, token{kind: tokLe, text: "<="})
+			i += 2
+
+		case c == '>':
+			toks = append(toks, token{kind: tokGt, text: ">"})
+			i++
+
+		case c == '<':
+			toks = append(toks, token{kind: tokLt, text: "<"})
+			i++
+
+		case c == '"':
+			start := i + 1
+			j := start
+			for j < len(r) && r[j] != '"' {
+				j++
+			}
+			if j >= len(r) {
+				return nil, fmt.Errorf("filter: unterminated string starting at %d", start)
+			}
+			toks = append(toks, token{kind: tokString, text: string(r[start:j])})
+			i = j + 1
+
+		case c == '-' || unicode.IsDigit(c):
+			start := i
+			j := i + 1
+			for j < len(r) && (unicode.IsDigit(r[j]) || r[j] == '.') {
+				j++
+			}
+			text := string(r[start:j])
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("filter: invalid number %q", text)
+			}
+			toks = append(toks, token{kind: tokNumber, text: text, num: n})
+			i = j
+
+		case isIdentStart(c):
+			start := i
+			j := i + 1
+			for j < len(r) && isIdentPart(r[j]) {
+				j++
+			}
+			text := string(r[start:j])
+			if kind, ok := keywords[text]; ok {
+				toks = append(toks, token{kind: kind, text: text})
+			} else {
+				toks = append(toks, token{kind: tokIdent, text: text})
+			}
+			i = j
+
+		default:
+			return nil, fmt.Errorf("filter: unexpected character %q at %d", c, i)
+		}
+	}
+
+	toks = append(toks, token{kind: tokEOF, text: ""})
+	return toks, nil
+}
+
+func isIdentStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func isIdentPart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'
+}
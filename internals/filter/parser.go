@@ -0,0 +1,220 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parser is a recursive-descent parser over the token stream produced by
+// lex, implementing (in ascending precedence): OR, AND, NOT, then a single
+// comparison or has(...) call.
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) atEnd() bool { return p.peek().kind == tokEOF }
+
+func (p *parser) advance() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("filter: "+format, args...)
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	if p.peek().kind != kind {
+		return token{}, p.errorf("expected %s, got %q", what, p.peek().text)
+	}
+	return p.advance(), nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.advance()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	switch p.peek().kind {
+	case tokLParen:
+		p.advance()
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return n, nil
+
+	case tokHas:
+		p.advance()
+		if _, err := p.expect(tokLParen, "'('"); err != nil {
+			return nil, err
+		}
+		opTok, err := p.expect(tokIdent, "an operand (attr.key, payload.path, topic, or ts)")
+		if err != nil {
+			return nil, err
+		}
+		op, err := parseOperand(opTok.text)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return hasNode{op: op}, nil
+
+	case tokIdent:
+		return p.parseComparison()
+
+	default:
+		return nil, p.errorf("unexpected token %q", p.peek().text)
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	opTok := p.advance()
+	op, err := parseOperand(opTok.text)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.peek().kind {
+	case tokIn:
+		p.advance()
+		if _, err := p.expect(tokLParen, "'('"); err != nil {
+			return nil, err
+		}
+		var literals []any
+		for {
+			lit, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			literals = append(literals, lit)
+			if p.peek().kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inNode{op: op, literals: literals}, nil
+
+	case tokEq, tokNe, tokGt, tokGe, tokLt, tokLe:
+		cmpOp := p.advance().kind
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{op: op, cmpOp: cmpOp, literal: lit}, nil
+
+	case tokContains:
+		p.advance()
+		lit, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return containsNode{op: op, literal: lit}, nil
+
+	default:
+		return nil, p.errorf("expected a comparison operator, IN, or CONTAINS after %q, got %q", opTok.text, p.peek().text)
+	}
+}
+
+func (p *parser) parseLiteral() (any, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokString:
+		return t.text, nil
+	case tokNumber:
+		return t.num, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, p.errorf("expected a string, number, or boolean literal, got %q", t.text)
+}
+
+// parseOperand splits a dotted identifier like "attr.region" or
+// "payload.order.total" into an operand, or recognizes the bare pseudo-field
+// names "topic" and "ts".
+func parseOperand(text string) (operand, error) {
+	switch text {
+	case "topic":
+		return operand{kind: opTopic}, nil
+	case "ts":
+		return operand{kind: opTs}, nil
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) < 2 {
+		return operand{}, fmt.Errorf("filter: operand %q must be topic, ts, or start with attr. or payload.", text)
+	}
+
+	switch parts[0] {
+	case "attr":
+		return operand{kind: opAttr, key: strings.Join(parts[1:], ".")}, nil
+	case "payload":
+		return operand{kind: opPayload, path: parts[1:]}, nil
+	default:
+		return operand{}, fmt.Errorf("filter: operand %q must be topic, ts, or start with attr. or payload.", text)
+	}
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes mounts GET /metrics on r, serving reg's collectors in the
+// Prometheus exposition format. If reg isn't backed by Prometheus (e.g. it's
+// Noop), the route responds 404, since there is nothing to scrape.
+//
+// This function mounts the following endpoint:
+//   - GET /metrics - Prometheus metrics exposition
+func RegisterRoutes(r chi.Router, reg Registry) {
+	promReg, ok := reg.(*PrometheusRegistry)
+	if !ok {
+		r.Get("/metrics", func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "metrics not enabled", http.StatusNotFound)
+		})
+		return
+	}
+
+	r.Get("/metrics", promReg.Handler().ServeHTTP)
+}
@@ -9,14 +9,36 @@ import (
 // Metrics tracks various metrics for the Pub/Sub system.
 type Metrics struct {
 	// Global counters
-	totalTopics      uint64
-	totalSubscribers uint64
-	totalMessages    uint64
-	totalDropped     uint64
+	totalTopics             uint64
+	totalSubscribers        uint64
+	totalMessages           uint64
+	totalDropped            uint64
+	totalDroppedSubscribers uint64
 
 	// Per-topic metrics
 	mu     sync.RWMutex
 	topics map[string]*TopicMetrics
+
+	// Per-topic, per-encoding compression metrics, keyed by
+	// compressionKey(topic, encoding).
+	compression map[string]*CompressionStats
+}
+
+// CompressionStats tracks the bytes moved through a given topic's payload
+// encoding, before and after compression, so operators can see the
+// compression ratio per topic and encoding.
+type CompressionStats struct {
+	Topic    string
+	Encoding string
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// compressionKey builds the map key IncCompressionBytes and
+// GetCompressionStats index by, so the same topic+encoding pair always
+// accumulates into the same CompressionStats.
+func compressionKey(topic, encoding string) string {
+	return topic + "|" + encoding
 }
 
 // TopicMetrics tracks metrics for a specific topic.
@@ -31,8 +53,40 @@ type TopicMetrics struct {
 // NewMetrics creates a new Metrics instance.
 func NewMetrics() *Metrics {
 	return &Metrics{
-		topics: make(map[string]*TopicMetrics),
+		topics:      make(map[string]*TopicMetrics),
+		compression: make(map[string]*CompressionStats),
+	}
+}
+
+// IncCompressionBytes records bytesIn (pre-compression) and bytesOut
+// (post-compression, or equal to bytesIn for "identity") for topic's
+// negotiated encoding.
+func (m *Metrics) IncCompressionBytes(topic, encoding string, bytesIn, bytesOut int) {
+	if bytesIn < 0 || bytesOut < 0 {
+		return
+	}
+
+	key := compressionKey(topic, encoding)
+	m.mu.Lock()
+	if m.compression[key] == nil {
+		m.compression[key] = &CompressionStats{Topic: topic, Encoding: encoding}
 	}
+	m.compression[key].BytesIn += uint64(bytesIn)
+	m.compression[key].BytesOut += uint64(bytesOut)
+	m.mu.Unlock()
+}
+
+// GetCompressionStats returns a snapshot of bytes-in/bytes-out per topic
+// and negotiated encoding, keyed by compressionKey(topic, encoding).
+func (m *Metrics) GetCompressionStats() map[string]CompressionStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]CompressionStats, len(m.compression))
+	for key, stats := range m.compression {
+		result[key] = *stats
+	}
+	return result
 }
 
 // IncPublished increments the published message counter for a topic.
@@ -79,6 +133,12 @@ func (m *Metrics) IncDropped(topic string, n int) {
 	m.mu.Unlock()
 }
 
+// IncDroppedSubscribers increments the counter of subscribers disconnected
+// for being too slow to keep up (their send buffer filled up).
+func (m *Metrics) IncDroppedSubscribers() {
+	atomic.AddUint64(&m.totalDroppedSubscribers, 1)
+}
+
 // IncTopics increments the total topics counter.
 func (m *Metrics) IncTopics() {
 	atomic.AddUint64(&m.totalTopics, 1)
@@ -126,10 +186,11 @@ func (m *Metrics) Snapshot() map[string]interface{} {
 
 	// Global metrics
 	snapshot["global"] = map[string]interface{}{
-		"topics":      atomic.LoadUint64(&m.totalTopics),
-		"subscribers": atomic.LoadUint64(&m.totalSubscribers),
-		"messages":    atomic.LoadUint64(&m.totalMessages),
-		"dropped":     atomic.LoadUint64(&m.totalDropped),
+		"topics":              atomic.LoadUint64(&m.totalTopics),
+		"subscribers":         atomic.LoadUint64(&m.totalSubscribers),
+		"messages":            atomic.LoadUint64(&m.totalMessages),
+		"dropped":             atomic.LoadUint64(&m.totalDropped),
+		"dropped_subscribers": atomic.LoadUint64(&m.totalDroppedSubscribers),
 	}
 
 	// Per-topic metrics
@@ -191,6 +252,7 @@ func (m *Metrics) Reset() {
 	atomic.StoreUint64(&m.totalSubscribers, 0)
 	atomic.StoreUint64(&m.totalMessages, 0)
 	atomic.StoreUint64(&m.totalDropped, 0)
+	atomic.StoreUint64(&m.totalDroppedSubscribers, 0)
 
 	m.mu.Lock()
 	for _, tm := range m.topics {
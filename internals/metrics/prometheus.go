@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusRegistry is the Registry implementation selected by
+// config.Config.MetricsBackend "prometheus". It registers its collectors on
+// a private prometheus.Registry, rather than the global default one, so
+// constructing more than one (e.g. in tests) doesn't panic on duplicate
+// registration.
+type PrometheusRegistry struct {
+	reg *prometheus.Registry
+
+	published      *prometheus.CounterVec
+	delivered      *prometheus.CounterVec
+	dropped        *prometheus.CounterVec
+	filtered       *prometheus.CounterVec
+	bufferDepth    *prometheus.GaugeVec
+	publishLatency *prometheus.HistogramVec
+	fanoutLatency  *prometheus.HistogramVec
+	activeConns    prometheus.Gauge
+	topics         prometheus.Gauge
+	subscribers    *prometheus.GaugeVec
+	topicsExpired  *prometheus.CounterVec
+	timeoutDiscs   *prometheus.CounterVec
+	batchSize      *prometheus.HistogramVec
+	batchFlushes   *prometheus.CounterVec
+}
+
+// NewPrometheusRegistry creates a PrometheusRegistry with its collectors
+// registered and ready to serve from Handler.
+func NewPrometheusRegistry() *PrometheusRegistry {
+	p := &PrometheusRegistry{
+		reg: prometheus.NewRegistry(),
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_messages_published_total",
+			Help: "Total messages published, by topic.",
+		}, []string{"topic"}),
+		delivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_messages_delivered_total",
+			Help: "Total messages delivered to a subscriber, by topic and client.",
+		}, []string{"topic", "client"}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_messages_dropped_total",
+			Help: "Total messages dropped, by topic and the overflow policy that dropped them.",
+		}, []string{"topic", "policy"}),
+		filtered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_messages_filtered_total",
+			Help: "Total deliveries skipped because a subscriber's filter expression rejected the message, by topic and client.",
+		}, []string{"topic", "client"}),
+		bufferDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pubsub_subscriber_buffer_depth",
+			Help: "Current number of buffered messages awaiting a subscriber, by topic and client.",
+		}, []string{"topic", "client"}),
+		publishLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pubsub_publish_latency_seconds",
+			Help:    "Time to fan a Publish call out to every subscriber, by topic.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic"}),
+		fanoutLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pubsub_fanout_latency_seconds",
+			Help:    "Time Publish spent iterating subscribers and attempting delivery to each, by topic.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"topic"}),
+		activeConns: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "pubsub_active_connections",
+			Help: "Current number of active subscriber connections.",
+		}),
+		topics: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "pubsub_topics",
+			Help: "Current number of registered topics.",
+		}),
+		subscribers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pubsub_subscribers",
+			Help: "Current number of active subscribers, by topic.",
+		}, []string{"topic"}),
+		topicsExpired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_topics_expired_total",
+			Help: "Total topics deleted by the idle-TTL reaper, by topic.",
+		}, []string{"topic"}),
+		timeoutDiscs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_subscriber_timeout_disconnects_total",
+			Help: "Total subscribers disconnected for a missed keepalive pong or failed ping write, by topic.",
+		}, []string{"topic"}),
+		batchSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pubsub_batch_size",
+			Help:    "Number of messages in each publish Bundler flush, by topic.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}, []string{"topic"}),
+		batchFlushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pubsub_batch_flushes_total",
+			Help: "Total publish Bundler flushes, by topic and trigger reason (count, bytes, delay, or close).",
+		}, []string{"topic", "reason"}),
+	}
+
+	p.reg.MustRegister(p.published, p.delivered, p.dropped, p.filtered, p.bufferDepth, p.publishLatency, p.fanoutLatency, p.activeConns, p.topics, p.subscribers, p.topicsExpired, p.timeoutDiscs, p.batchSize, p.batchFlushes)
+	return p
+}
+
+func (p *PrometheusRegistry) ObservePublished(topic string) {
+	p.published.WithLabelValues(topic).Inc()
+}
+
+func (p *PrometheusRegistry) ObservePublishLatency(topic string, d time.Duration) {
+	p.publishLatency.WithLabelValues(topic).Observe(d.Seconds())
+}
+
+func (p *PrometheusRegistry) ObserveFanoutLatency(topic string, d time.Duration) {
+	p.fanoutLatency.WithLabelValues(topic).Observe(d.Seconds())
+}
+
+func (p *PrometheusRegistry) ObserveDelivered(topic, client string) {
+	p.delivered.WithLabelValues(topic, client).Inc()
+}
+
+func (p *PrometheusRegistry) ObserveDropped(topic, policy string) {
+	p.dropped.WithLabelValues(topic, policy).Inc()
+}
+
+func (p *PrometheusRegistry) ObserveFiltered(topic, client string) {
+	p.filtered.WithLabelValues(topic, client).Inc()
+}
+
+func (p *PrometheusRegistry) SetBufferDepth(topic, client string, depth int) {
+	p.bufferDepth.WithLabelValues(topic, client).Set(float64(depth))
+}
+
+func (p *PrometheusRegistry) DeleteBufferDepth(topic, client string) {
+	p.bufferDepth.DeleteLabelValues(topic, client)
+}
+
+func (p *PrometheusRegistry) IncActiveConnections() {
+	p.activeConns.Inc()
+}
+
+func (p *PrometheusRegistry) DecActiveConnections() {
+	p.activeConns.Dec()
+}
+
+func (p *PrometheusRegistry) SetTopics(count int) {
+	p.topics.Set(float64(count))
+}
+
+func (p *PrometheusRegistry) SetSubscribers(topic string, count int) {
+	p.subscribers.WithLabelValues(topic).Set(float64(count))
+}
+
+func (p *PrometheusRegistry) ObserveTopicExpired(topic string) {
+	p.topicsExpired.WithLabelValues(topic).Inc()
+}
+
+func (p *PrometheusRegistry) ObserveTimeoutDisconnect(topic string) {
+	p.timeoutDiscs.WithLabelValues(topic).Inc()
+}
+
+func (p *PrometheusRegistry) ObserveBatchSize(topic string, size int) {
+	p.batchSize.WithLabelValues(topic).Observe(float64(size))
+}
+
+func (p *PrometheusRegistry) ObserveBatchFlush(topic, reason string) {
+	p.batchFlushes.WithLabelValues(topic, reason).Inc()
+}
+
+// Handler returns the http.Handler that serves this registry's collectors
+// in the Prometheus exposition format.
+func (p *PrometheusRegistry) Handler() http.Handler {
+	return promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{})
+}
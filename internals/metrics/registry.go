@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+)
+
+// Registry is the instrumentation sink wired into a Topic's publish and
+// subscriber lifecycle. It mirrors the shape of transport.Transport: a
+// small interface with a no-op default (Noop) and a real implementation
+// (PrometheusRegistry) selected via config.MetricsBackend, so the hot path
+// pays nothing when metrics aren't enabled.
+type Registry interface {
+	// ObservePublished records a message published to topic.
+	ObservePublished(topic string)
+
+	// ObservePublishLatency records how long a single Topic.Publish call
+	// took to fan out to every subscriber.
+	ObservePublishLatency(topic string, d time.Duration)
+
+	// ObserveFanoutLatency records how long Publish spent iterating
+	// subscribers and attempting delivery to each, excluding the ring
+	// buffer write and filter setup that precede it.
+	ObserveFanoutLatency(topic string, d time.Duration)
+
+	// ObserveDelivered records a message delivered to client on topic.
+	ObserveDelivered(topic, client string)
+
+	// ObserveDropped records a message dropped for topic under the named
+	// overflow policy (see topic.OverflowPolicy.Name).
+	ObserveDropped(topic, policy string)
+
+	// ObserveFiltered records a delivery to client on topic skipped because
+	// the subscriber's filter expression rejected the message (see
+	// internals/filter), distinct from ObserveDropped.
+	ObserveFiltered(topic, client string)
+
+	// SetBufferDepth reports client's current buffered-message count on
+	// topic, e.g. after a delivery attempt.
+	SetBufferDepth(topic, client string, depth int)
+
+	// DeleteBufferDepth clears a previously reported buffer depth, e.g.
+	// when client disconnects, so the series doesn't linger forever.
+	DeleteBufferDepth(topic, client string)
+
+	// IncActiveConnections records a new subscriber connection.
+	IncActiveConnections()
+
+	// DecActiveConnections records a subscriber connection closing.
+	DecActiveConnections()
+
+	// SetTopics reports the current number of registered topics.
+	SetTopics(count int)
+
+	// SetSubscribers reports topic's current active subscriber count.
+	SetSubscribers(topic string, count int)
+
+	// ObserveTopicExpired records topic being deleted by the idle-TTL
+	// reaper, distinct from an explicit DeleteTopic call.
+	ObserveTopicExpired(topic string)
+
+	// ObserveTimeoutDisconnect records a subscriber on topic being torn
+	// down because its WebSocket keepalive timed out (a missed pong or a
+	// failed ping write), distinct from a client-initiated disconnect, so
+	// operators can see silent client disconnects.
+	ObserveTimeoutDisconnect(topic string)
+
+	// ObserveBatchSize records the number of messages a publish Bundler
+	// flushed in one topic.Topic.PublishBatch call for topic.
+	ObserveBatchSize(topic string, size int)
+
+	// ObserveBatchFlush records a publish Bundler flush for topic,
+	// triggered by reason ("count", "bytes", "delay", or "close").
+	ObserveBatchFlush(topic, reason string)
+}
+
+// noopRegistry is the default Registry: every method is a no-op.
+type noopRegistry struct{}
+
+// Noop returns a Registry that discards everything, for deployments that
+// don't set MetricsBackend.
+func Noop() Registry { return noopRegistry{} }
+
+func (noopRegistry) ObservePublished(string)                     {}
+func (noopRegistry) ObservePublishLatency(string, time.Duration) {}
+func (noopRegistry) ObserveFanoutLatency(string, time.Duration)  {}
+func (noopRegistry) ObserveDelivered(string, string)             {}
+func (noopRegistry) ObserveDropped(string, string)               {}
+func (noopRegistry) ObserveFiltered(string, string)              {}
+func (noopRegistry) SetBufferDepth(string, string, int)          {}
+func (noopRegistry) DeleteBufferDepth(string, string)            {}
+func (noopRegistry) IncActiveConnections()                       {}
+func (noopRegistry) DecActiveConnections()                       {}
+func (noopRegistry) SetTopics(int)                               {}
+func (noopRegistry) SetSubscribers(string, int)                  {}
+func (noopRegistry) ObserveTopicExpired(string)                  {}
+func (noopRegistry) ObserveTimeoutDisconnect(string)             {}
+func (noopRegistry) ObserveBatchSize(string, int)                {}
+func (noopRegistry) ObserveBatchFlush(string, string)            {}
+
+// RegistryFromBackend resolves a config.Config.MetricsBackend name ("none"
+// or "prometheus") into a Registry. Returns an error for an unrecognized
+// name.
+func RegistryFromBackend(backend string) (Registry, error) {
+	switch backend {
+	case "none", "":
+		return Noop(), nil
+	case "prometheus":
+		return NewPrometheusRegistry(), nil
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", backend)
+	}
+}
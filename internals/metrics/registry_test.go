@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRegistryFromBackend(t *testing.T) {
+	if _, ok := mustRegistry(t, "").(noopRegistry); !ok {
+		t.Error("expected empty backend to resolve to noopRegistry")
+	}
+	if _, ok := mustRegistry(t, "none").(noopRegistry); !ok {
+		t.Error("expected \"none\" to resolve to noopRegistry")
+	}
+	if _, ok := mustRegistry(t, "prometheus").(*PrometheusRegistry); !ok {
+		t.Error("expected \"prometheus\" to resolve to *PrometheusRegistry")
+	}
+
+	if _, err := RegistryFromBackend("bogus"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+func mustRegistry(t *testing.T, backend string) Registry {
+	t.Helper()
+	reg, err := RegistryFromBackend(backend)
+	if err != nil {
+		t.Fatalf("RegistryFromBackend(%q): %v", backend, err)
+	}
+	return reg
+}
+
+func TestPrometheusRegistry_Handler(t *testing.T) {
+	p := NewPrometheusRegistry()
+	p.ObservePublished("orders")
+	p.ObserveDelivered("orders", "client-1")
+	p.ObserveDropped("orders", "DROP_OLDEST")
+	p.SetBufferDepth("orders", "client-1", 3)
+	p.ObservePublishLatency("orders", 10*time.Millisecond)
+	p.ObserveFanoutLatency("orders", 5*time.Millisecond)
+	p.IncActiveConnections()
+	p.SetTopics(2)
+	p.SetSubscribers("orders", 1)
+	p.ObserveTopicExpired("orders")
+	p.ObserveTimeoutDisconnect("orders")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`pubsub_messages_published_total{topic="orders"} 1`,
+		`pubsub_messages_delivered_total{client="client-1",topic="orders"} 1`,
+		`pubsub_messages_dropped_total{policy="DROP_OLDEST",topic="orders"} 1`,
+		`pubsub_subscriber_buffer_depth{client="client-1",topic="orders"} 3`,
+		`pubsub_active_connections 1`,
+		`pubsub_topics 2`,
+		`pubsub_subscribers{topic="orders"} 1`,
+		`pubsub_fanout_latency_seconds_count{topic="orders"} 1`,
+		`pubsub_topics_expired_total{topic="orders"} 1`,
+		`pubsub_subscriber_timeout_disconnects_total{topic="orders"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics output to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	p.DeleteBufferDepth("orders", "client-1")
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, req)
+	if strings.Contains(rec.Body.String(), "pubsub_subscriber_buffer_depth") {
+		t.Error("expected buffer depth series to be removed after DeleteBufferDepth")
+	}
+}
+
+func TestRegisterRoutes_Noop(t *testing.T) {
+	r := chi.NewRouter()
+	RegisterRoutes(r, Noop())
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a noop registry, got %d", rec.Code)
+	}
+}
+
+func TestRegisterRoutes_Prometheus(t *testing.T) {
+	r := chi.NewRouter()
+	p := NewPrometheusRegistry()
+	p.ObservePublished("orders")
+	RegisterRoutes(r, p)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "pubsub_messages_published_total") {
+		t.Error("expected the exposition body to contain published counter")
+	}
+}
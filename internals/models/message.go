@@ -10,16 +10,86 @@ import (
 type Message struct {
 	ID      string          `json:"id"`
 	Payload json.RawMessage `json:"payload"`
+
+	// Seq is a monotonically increasing per-topic sequence number assigned
+	// by the ring buffer on Push. It is stable across restarts when the
+	// ring buffer is backed by a WAL.
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Created is the time the message was assigned its sequence number.
+	Created time.Time `json:"created,omitempty"`
+
+	// Origin names the cluster node ID that first published this message,
+	// set by internals/cluster when a message arrives forwarded from a
+	// peer. Empty means the message originated on this node. A publish
+	// whose Origin is already set is delivered to local subscribers but
+	// not forwarded on again, which is what keeps a cluster from looping a
+	// message between peers forever.
+	Origin string `json:"origin,omitempty"`
+
+	// Attributes holds publisher-supplied key/value metadata, mirroring
+	// Google Pub/Sub attributes, that a subscriber's filter expression can
+	// match against (see internals/filter) without having to parse Payload.
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// DeadLetter is the envelope a message is wrapped in before being
+// republished to a topic's DLQ topic after delivery to ClientID failed
+// (e.g. its buffer overflowed, or it exhausted its subscriber.RetryPolicy
+// attempts). See registry.Registry.SetDLQPolicy.
+type DeadLetter struct {
+	OriginalTopic string    `json:"original_topic"`
+	ClientID      string    `json:"client_id"`
+	Message       Message   `json:"message"`
+	Reason        string    `json:"reason"`
+	Ts            time.Time `json:"ts"`
 }
 
 // WSClientMsg represents a WebSocket client message with various operation types.
 type WSClientMsg struct {
-	Type      string   `json:"type"`
-	Topic     string   `json:"topic,omitempty"`
-	ClientID  string   `json:"client_id,omitempty"`
-	LastN     int      `json:"last_n,omitempty"`
-	Message   *Message `json:"message,omitempty"`
-	RequestID string   `json:"request_id,omitempty"`
+	Type     string   `json:"type"`
+	Topic    string   `json:"topic,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	LastN    int      `json:"last_n,omitempty"`
+	Message  *Message `json:"message,omitempty"`
+
+	// RequestID correlates this message with its ServerMsg reply for most
+	// types. For a "response" message specifically, it instead carries the
+	// correlation ID of the registry.Registry.Request call being answered,
+	// and Message holds the reply payload (see
+	// subscriber.Subscriber.SetMessageHandler).
+	RequestID string `json:"request_id,omitempty"`
+
+	// From, when subscribing, asks for replay of every buffered message
+	// with Seq >= From before switching to live delivery. Zero means
+	// "start from live only", unless FromOldest is set.
+	From uint64 `json:"from,omitempty"`
+
+	// FromOldest, when subscribing, asks for replay starting at the oldest
+	// sequence the topic still retains (in the ring buffer or its WAL),
+	// without the client needing to know that sequence number up front. It
+	// takes precedence over From.
+	FromOldest bool `json:"from_oldest,omitempty"`
+
+	// Compression, when subscribing, negotiates the payload encoding used
+	// for subsequent deliveries on this connection: "identity", "gzip",
+	// "deflate", or "br". Empty means "identity".
+	Compression string `json:"compression,omitempty"`
+
+	// ID, for an "ack", "nack", or "modack" message, names the message ID
+	// being acknowledged, negatively acknowledged, or extended under an
+	// AckMode subscription (see topic.AckModePolicy).
+	ID string `json:"id,omitempty"`
+
+	// AckDeadline, for a "modack" message, is how much longer from now the
+	// message named by ID gets before its ack deadline expires again.
+	AckDeadline time.Duration `json:"ack_deadline,omitempty"`
+
+	// Filter, when subscribing, is an optional expression in the
+	// internals/filter predicate language. Only messages matching it are
+	// delivered to this subscription; non-matching messages don't count as
+	// dropped. Empty means no filtering.
+	Filter string `json:"filter,omitempty"`
 }
 
 // ServerMsg represents a server response message with optional error handling.
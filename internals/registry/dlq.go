@@ -0,0 +1,292 @@
+package registry
+
+import (
+	"container/heap"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+)
+
+// DLQPolicy configures how a registry reacts to a subscriber buffer
+// overflow on one topic: which DLQ topic the dropped message is
+// republished to, and the subscriber.RetryPolicy that backstops it for a
+// subscriber that didn't set one of its own. See Registry.SetDLQPolicy.
+type DLQPolicy struct {
+	// Topic is the DLQ topic a dropped message is republished to. Empty
+	// means the default "__dlq.<originalTopic>".
+	Topic string
+
+	// RetryPolicy applies to a dropped delivery when the subscriber that
+	// dropped it has none set via subscriber.Subscriber.SetRetryPolicy. A
+	// zero value (MaxAttempts == 0) means such a drop goes straight to the
+	// DLQ without retrying.
+	RetryPolicy subscriber.RetryPolicy
+}
+
+// dlqTopicName resolves the DLQ topic originalTopic's drops are
+// republished to, applying the "__dlq." default when p.Topic is unset.
+func (p DLQPolicy) dlqTopicName(originalTopic string) string {
+	if p.Topic != "" {
+		return p.Topic
+	}
+	return "__dlq." + originalTopic
+}
+
+// SetDLQPolicy configures topicName's DLQ topic and default
+// subscriber.RetryPolicy (see DLQPolicy). Call this before traffic flows
+// for topicName; it has no retroactive effect on retries already
+// scheduled.
+func (r *Registry) SetDLQPolicy(topicName string, policy DLQPolicy) {
+	r.dlqMu.Lock()
+	defer r.dlqMu.Unlock()
+	r.dlqPolicies[topicName] = policy
+}
+
+// dlqPolicyFor returns topicName's configured DLQPolicy, or the zero value
+// (default DLQ topic, no retry) if none was set.
+func (r *Registry) dlqPolicyFor(topicName string) DLQPolicy {
+	r.dlqMu.Lock()
+	defer r.dlqMu.Unlock()
+	return r.dlqPolicies[topicName]
+}
+
+// handleDrop implements topic.DropHandler: msg failed to be delivered to
+// sub on topicName because sub's buffer overflowed. sub's own
+// subscriber.RetryPolicy takes precedence over topicName's DLQPolicy
+// default; if neither allows any attempts, msg is dead-lettered
+// immediately. Otherwise it is handed to the retry scheduler for
+// redelivery with backoff.
+func (r *Registry) handleDrop(topicName string, sub *subscriber.Subscriber, msg models.Message) {
+	policy := sub.RetryPolicy()
+	if policy.MaxAttempts == 0 {
+		policy = r.dlqPolicyFor(topicName).RetryPolicy
+	}
+
+	if policy.MaxAttempts == 0 {
+		r.deadLetterDrop(topicName, sub.GetClientID(), msg, "buffer_overflow")
+		return
+	}
+
+	r.retry.schedule(&retryEntry{
+		topicName:   topicName,
+		sub:         sub,
+		msg:         msg,
+		policy:      policy,
+		nextAttempt: time.Now().Add(policy.NextBackoff(1)),
+	})
+}
+
+// deadLetterDrop wraps msg in a models.DeadLetter and republishes it to
+// topicName's DLQ topic (see DLQPolicy.dlqTopicName), auto-creating that
+// topic on first use, then records the drop against the original topic's
+// dead-letter counter.
+func (r *Registry) deadLetterDrop(topicName, clientID string, msg models.Message, reason string) {
+	dlqTopicName := r.dlqPolicyFor(topicName).dlqTopicName(topicName)
+
+	dlqTopic, err := r.GetOrCreateTopic(dlqTopicName)
+	if err != nil {
+		log.Printf("registry: dead-letter drop from %q to %q: %v", topicName, dlqTopicName, err)
+		return
+	}
+
+	payload, err := json.Marshal(models.DeadLetter{
+		OriginalTopic: topicName,
+		ClientID:      clientID,
+		Message:       msg,
+		Reason:        reason,
+		Ts:            time.Now(),
+	})
+	if err != nil {
+		log.Printf("registry: marshal dead letter from %q: %v", topicName, err)
+		return
+	}
+	dlqTopic.Publish(models.Message{ID: msg.ID, Payload: payload}, topic.DropOldest())
+
+	if orig, exists := r.GetTopic(topicName); exists {
+		orig.IncDeadLettered()
+	}
+}
+
+// attemptRedeliver is retryScheduler's redeliver callback: it re-attempts
+// delivery of e.msg to e.sub on e.topicName, incrementing the original
+// topic's redelivered counter on success. Returns true if the scheduler
+// should stop retrying this entry (delivered, or the topic/subscriber is
+// gone), false if it should be rescheduled with backoff.
+func (r *Registry) attemptRedeliver(e *retryEntry) bool {
+	t, exists := r.GetTopic(e.topicName)
+	if !exists || !e.sub.IsActive() {
+		return true
+	}
+
+	if t.DeliverTo(e.sub, e.msg, topic.DropNewest()) {
+		t.IncRedelivered()
+		return true
+	}
+	return false
+}
+
+// exhaustRetry is retryScheduler's onExhausted callback: e's
+// subscriber.RetryPolicy ran out of attempts, so the message is
+// dead-lettered instead.
+func (r *Registry) exhaustRetry(e *retryEntry) {
+	r.deadLetterDrop(e.topicName, e.sub.GetClientID(), e.msg, "retry_exhausted")
+}
+
+// retryEntry is one scheduled redelivery attempt, ordered by nextAttempt
+// in the retryScheduler's min-heap.
+type retryEntry struct {
+	topicName   string
+	sub         *subscriber.Subscriber
+	msg         models.Message
+	attempt     int
+	policy      subscriber.RetryPolicy
+	nextAttempt time.Time
+}
+
+// retryHeap implements container/heap.Interface ordered by nextAttempt,
+// earliest first.
+type retryHeap []*retryEntry
+
+func (h retryHeap) Len() int           { return len(h) }
+func (h retryHeap) Less(i, j int) bool { return h[i].nextAttempt.Before(h[j].nextAttempt) }
+func (h retryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *retryHeap) Push(x any) {
+	*h = append(*h, x.(*retryEntry))
+}
+
+func (h *retryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// retryScheduler redelivers messages dropped by an overflowing subscriber
+// buffer according to each one's subscriber.RetryPolicy, dead-lettering it
+// via onExhausted once attempts run out. A single background goroutine
+// drives a min-heap keyed by next-attempt time, so it wakes only when the
+// earliest pending retry is due instead of polling every scheduled retry
+// on a fixed interval.
+type retryScheduler struct {
+	mu   sync.Mutex
+	heap retryHeap
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// redeliver attempts one delivery for an entry popped off the heap. It
+	// returns true if the scheduler should stop retrying it (delivered, or
+	// there's nothing left to deliver to), false if it should be
+	// rescheduled with backoff.
+	redeliver func(*retryEntry) bool
+
+	// onExhausted is called once an entry's policy.MaxAttempts is reached
+	// without a successful redeliver.
+	onExhausted func(*retryEntry)
+}
+
+// newRetryScheduler starts a retryScheduler's background goroutine and
+// returns it. Callers must eventually call close to stop it.
+func newRetryScheduler(redeliver func(*retryEntry) bool, onExhausted func(*retryEntry)) *retryScheduler {
+	s := &retryScheduler{
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		redeliver:   redeliver,
+		onExhausted: onExhausted,
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// schedule adds e to the heap and wakes the scheduler goroutine if e is
+// now the earliest pending entry.
+func (s *retryScheduler) schedule(e *retryEntry) {
+	s.mu.Lock()
+	heap.Push(&s.heap, e)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run waits until the earliest scheduled entry is due, fires every entry
+// that has come due, and repeats, until close stops it.
+func (s *retryScheduler) run() {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		wait := time.Hour
+		if len(s.heap) > 0 {
+			wait = time.Until(s.heap[0].nextAttempt)
+			if wait < 0 {
+				wait = 0
+			}
+		}
+		s.mu.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		case <-timer.C:
+			s.fireDue()
+		}
+	}
+}
+
+// fireDue pops and redelivers every entry whose nextAttempt has passed,
+// rescheduling any that failed and haven't exhausted their attempts.
+func (s *retryScheduler) fireDue() {
+	now := time.Now()
+	for {
+		s.mu.Lock()
+		if len(s.heap) == 0 || s.heap[0].nextAttempt.After(now) {
+			s.mu.Unlock()
+			return
+		}
+		e := heap.Pop(&s.heap).(*retryEntry)
+		s.mu.Unlock()
+
+		if s.redeliver(e) {
+			continue
+		}
+
+		e.attempt++
+		if e.attempt >= e.policy.MaxAttempts {
+			s.onExhausted(e)
+			continue
+		}
+		e.nextAttempt = now.Add(e.policy.NextBackoff(e.attempt + 1))
+		s.schedule(e)
+	}
+}
+
+// close stops the scheduler's goroutine and waits for it to exit. Any
+// entries still pending in the heap are dropped without being dead-lettered.
+func (s *retryScheduler) close() {
+	close(s.done)
+	s.wg.Wait()
+}
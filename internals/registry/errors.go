@@ -11,4 +11,15 @@ var (
 
 	// ErrTopicNotFound is returned when trying to access a topic that doesn't exist
 	ErrTopicNotFound = errors.New("topic not found")
+
+	// ErrOversizedMessage is returned by PublishMessageAsync when a
+	// message's payload exceeds cfg.MaxMessageBytes, mirroring the
+	// client-side size check Google's Cloud Pub/Sub client performs before
+	// it ever attempts a publish.
+	ErrOversizedMessage = errors.New("message payload exceeds max message bytes")
+
+	// ErrWALNotConfigured is returned by CreateDurableTopic when the
+	// registry was started without cfg.WALDir set, so there is nowhere on
+	// disk to place the topic's WAL.
+	ErrWALNotConfigured = errors.New("registry: WAL_DIR not configured, cannot create a durable topic")
 )
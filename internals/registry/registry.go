@@ -2,76 +2,372 @@
 package registry
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/ringbuffer"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
 )
 
 // TopicInfo provides information about a topic for listing and monitoring.
 type TopicInfo struct {
-	Name           string `json:"name"`
-	Subscribers    int    `json:"subscribers"`
-	Messages       uint64 `json:"messages"`
-	Dropped        uint64 `json:"dropped"`
-	RingBufferSize int    `json:"ring_buffer_size"`
+	Name           string        `json:"name"`
+	Subscribers    int           `json:"subscribers"`
+	Messages       uint64        `json:"messages"`
+	Dropped        uint64        `json:"dropped"`
+	RingBufferSize int           `json:"ring_buffer_size"`
+	TTL            time.Duration `json:"ttl,omitempty"`
+	LastActivity   string        `json:"last_activity,omitempty"`
+
+	// ExpiresAt is when this topic will become eligible for idle reaping if
+	// it sees no further activity, formatted like LastActivity. Empty when
+	// TTL is zero.
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	// LastSeq is the sequence number of the most recently published
+	// message, or 0 if the topic has published nothing yet.
+	LastSeq uint64 `json:"last_seq,omitempty"`
 }
 
 // TopicStats provides detailed statistics for a topic.
 type TopicStats struct {
-	Name           string `json:"name"`
-	Subscribers    int    `json:"subscribers"`
-	Messages       uint64 `json:"messages"`
-	Dropped        uint64 `json:"dropped"`
-	RingBufferSize int    `json:"ring_buffer_size"`
-	LastPublish    string `json:"last_publish,omitempty"`
-	LastActivity   string `json:"last_activity,omitempty"`
+	Name           string        `json:"name"`
+	Subscribers    int           `json:"subscribers"`
+	Messages       uint64        `json:"messages"`
+	Dropped        uint64        `json:"dropped"`
+	RingBufferSize int           `json:"ring_buffer_size"`
+	TTL            time.Duration `json:"ttl,omitempty"`
+	LastPublish    string        `json:"last_publish,omitempty"`
+	LastActivity   string        `json:"last_activity,omitempty"`
+
+	// ExpiresAt is when this topic will become eligible for idle reaping if
+	// it sees no further activity, formatted like LastActivity. Empty when
+	// TTL is zero.
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	// DeadLettered is the total number of messages sent to this topic's
+	// dead-letter topic, whether via AckMode exhausting its delivery
+	// attempts or an overflow drop exhausting its subscriber.RetryPolicy.
+	DeadLettered uint64 `json:"dead_lettered,omitempty"`
+
+	// Retried is the total number of successful redeliveries: an AckMode
+	// redelivery after a missed ack, or the registry's retryScheduler
+	// redelivering an overflow drop.
+	Retried uint64 `json:"retried,omitempty"`
+}
+
+// TopicOptions bundles the optional per-topic settings accepted by
+// CreateTopicWithOptions. The zero value matches CreateTopic's defaults.
+type TopicOptions struct {
+	// TTL, if positive, is the idle-expiry duration passed to
+	// CreateTopicWithTTL. Zero means the registry's configured
+	// cfg.DefaultTopicTTL applies.
+	TTL time.Duration
+}
+
+// DurableOpts configures the on-disk durability of a topic created via
+// CreateDurableTopic, overriding the registry's cfg.WAL* defaults for that
+// topic alone. A zero field falls back to the corresponding cfg default.
+type DurableOpts struct {
+	// TTL, if positive, is the topic's idle-expiry duration, with the same
+	// meaning as CreateTopicWithTTL's ttl. Zero means the registry's
+	// configured cfg.DefaultTopicTTL applies.
+	TTL time.Duration
+
+	// FsyncPolicy overrides cfg.WALFsyncPolicy for this topic's WAL.
+	FsyncPolicy ringbuffer.FsyncPolicy
+
+	// Retention overrides cfg.WALRetention for this topic's WAL.
+	Retention time.Duration
+
+	// MaxTotalBytes overrides cfg.WALMaxTotalBytes for this topic's WAL.
+	MaxTotalBytes int64
 }
 
 // Registry manages all topics in the Pub/Sub system with thread-safe operations.
 type Registry struct {
-	topics  map[string]*topic.Topic
-	mu      sync.RWMutex
-	cfg     *config.Config
-	metrics *metrics.Metrics
+	topics        *topicShardMap
+	cfg           *config.Config
+	metrics       *metrics.Metrics
+	defaultPolicy topic.OverflowPolicy
+	promRegistry  metrics.Registry
+
+	// pending tracks in-flight Request calls by correlation ID, so Respond
+	// (reached via HandleClientMessage once a subscriber replies) knows
+	// which caller is waiting.
+	pendingMu sync.Mutex
+	pending   map[string]chan models.ServerMsg
+
+	// bundlers holds a lazily-created publish Bundler per topic name, used
+	// by PublishMessageAsync to batch publishes. See bundler.go.
+	bundlersMu sync.Mutex
+	bundlers   map[string]*bundler
+
+	// trie indexes hierarchical/wildcard subscription patterns registered
+	// via SubscribePattern, consulted by PublishMessage in addition to the
+	// concrete topic's own subscriber map. See trie.go.
+	trie *TopicTrie
+
+	// dlqPolicies holds the per-topic DLQPolicy registered via
+	// SetDLQPolicy, keyed by the original topic name. See dlq.go.
+	dlqMu       sync.Mutex
+	dlqPolicies map[string]DLQPolicy
+
+	// retry redelivers messages dropped by an overflowing subscriber
+	// buffer according to the dropping subscriber's retry policy,
+	// dead-lettering them once it's exhausted. Wired into every topic as
+	// its DropHandler. See dlq.go.
+	retry *retryScheduler
+}
+
+// NewRegistry creates a new topic registry with the specified configuration
+// and metrics. It resolves cfg.DefaultPublishPolicy into a topic.OverflowPolicy
+// and cfg.MetricsBackend into a metrics.Registry once, falling back to
+// topic.DropOldest and metrics.Noop respectively for an unrecognized name.
+func NewRegistry(cfg *config.Config, m *metrics.Metrics) *Registry {
+	policy, err := topic.PolicyFromName(cfg.DefaultPublishPolicy, cfg.DefaultBlockTimeout, cfg.DefaultAckTimeout, cfg.DefaultMaxDeliveryAttempts)
+	if err != nil {
+		log.Printf("registry: %v; falling back to DROP_OLDEST", err)
+		policy = topic.DropOldest()
+	}
+
+	promRegistry, err := metrics.RegistryFromBackend(cfg.MetricsBackend)
+	if err != nil {
+		log.Printf("registry: %v; falling back to no-op metrics", err)
+		promRegistry = metrics.Noop()
+	}
+
+	r := &Registry{
+		topics:        newTopicShardMap(cfg.TopicShards),
+		cfg:           cfg,
+		metrics:       m,
+		defaultPolicy: policy,
+		promRegistry:  promRegistry,
+		pending:       make(map[string]chan models.ServerMsg),
+		bundlers:      make(map[string]*bundler),
+		trie:          newTopicTrie(),
+		dlqPolicies:   make(map[string]DLQPolicy),
+	}
+	r.retry = newRetryScheduler(r.attemptRedeliver, r.exhaustRetry)
+	return r
+}
+
+// CreateTopic creates a new topic with the specified name, applying
+// cfg.DefaultTopicTTL (zero means no TTL) unless the caller needs a
+// different one, in which case use CreateTopicWithTTL or
+// CreateTopicWithOptions. Returns an error if the topic already exists.
+func (r *Registry) CreateTopic(name string) error {
+	return r.CreateTopicWithTTL(name, r.cfg.DefaultTopicTTL)
 }
 
-// NewRegistry creates a new topic registry with the specified configuration and metrics.
-func NewRegistry(cfg *config.Config, metrics *metrics.Metrics) *Registry {
-	return &Registry{
-		topics:  make(map[string]*topic.Topic),
-		cfg:     cfg,
-		metrics: metrics,
+// CreateTopicWithOptions creates a new topic with the specified name,
+// applying opts. A zero opts.TTL falls back to cfg.DefaultTopicTTL, the
+// same as CreateTopic. TopicOptions is the extension point for future
+// per-topic settings beyond TTL. Returns an error if the topic already
+// exists.
+func (r *Registry) CreateTopicWithOptions(name string, opts TopicOptions) error {
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = r.cfg.DefaultTopicTTL
 	}
+	return r.CreateTopicWithTTL(name, ttl)
 }
 
-// CreateTopic creates a new topic with the specified name.
+// CreateTopicWithTTL creates a new topic with the specified name that is
+// automatically deleted once it has had no activity (publish or subscribe)
+// for longer than ttl and has no active subscribers. A zero ttl means the
+// topic is never reaped for being idle.
 // Returns an error if the topic already exists.
-func (r *Registry) CreateTopic(name string) error {
+func (r *Registry) CreateTopicWithTTL(name string, ttl time.Duration) error {
+	if name == "" {
+		return ErrInvalidTopicName
+	}
+
+	_, loaded, err := r.topics.loadOrCreate(name, func() (*topic.Topic, error) {
+		// Create new topic, backing its ring buffer with a WAL when the
+		// registry is configured for durable persistence.
+		newTopic, err := r.newTopic(name)
+		if err != nil {
+			return nil, fmt.Errorf("registry: create topic %q: %w", name, err)
+		}
+		newTopic.SetTTL(ttl)
+		return newTopic, nil
+	}, func(*topic.Topic) {
+		r.metrics.IncTopics()
+		r.promRegistry.SetTopics(r.topics.len())
+	})
+	if err != nil {
+		return err
+	}
+	if loaded {
+		return ErrTopicAlreadyExists
+	}
+
+	log.Printf("Created topic: %s", name)
+	return nil
+}
+
+// CreateDurableTopic creates name with its ring buffer explicitly backed
+// by an on-disk WAL under cfg.WALDir, using opts to override the
+// registry's default fsync policy, retention, and size cap for this topic
+// alone. Returns ErrWALNotConfigured if the registry was started without
+// cfg.WALDir, and ErrTopicAlreadyExists if name is already in use. Unlike
+// CreateTopic, which silently falls back to an in-memory ring buffer when
+// cfg.WALDir is unset, CreateDurableTopic fails loudly instead, since a
+// caller asking for durability needs to know it didn't get it.
+func (r *Registry) CreateDurableTopic(name string, opts DurableOpts) error {
+	if r.cfg.WALDir == "" {
+		return ErrWALNotConfigured
+	}
 	if name == "" {
 		return ErrInvalidTopicName
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	_, loaded, err := r.topics.loadOrCreate(name, func() (*topic.Topic, error) {
+		walCfg := ringbuffer.WALConfig{
+			Dir:           r.walPath(name),
+			FsyncPolicy:   opts.FsyncPolicy,
+			FsyncInterval: r.cfg.WALFsyncInterval,
+			Retention:     opts.Retention,
+			MaxTotalBytes: opts.MaxTotalBytes,
+		}
+		if walCfg.FsyncPolicy == "" {
+			walCfg.FsyncPolicy = ringbuffer.FsyncPolicy(r.cfg.WALFsyncPolicy)
+		}
+		if walCfg.Retention == 0 {
+			walCfg.Retention = r.cfg.WALRetention
+		}
+		if walCfg.MaxTotalBytes == 0 {
+			walCfg.MaxTotalBytes = r.cfg.WALMaxTotalBytes
+		}
 
-	// Check if topic already exists
-	if _, exists := r.topics[name]; exists {
+		ring, err := ringbuffer.NewRingBufferWithWALConfig(r.cfg.DefaultRingBufferSize, walCfg)
+		if err != nil {
+			return nil, fmt.Errorf("registry: create durable topic %q: %w", name, err)
+		}
+		newTopic := topic.NewTopicWithRing(name, ring)
+		newTopic.SetMetricsRegistry(r.promRegistry)
+		newTopic.SetMessageHandler(r)
+		r.wireDropHandler(newTopic, name)
+
+		ttl := opts.TTL
+		if ttl == 0 {
+			ttl = r.cfg.DefaultTopicTTL
+		}
+		newTopic.SetTTL(ttl)
+		return newTopic, nil
+	}, func(*topic.Topic) {
+		r.metrics.IncTopics()
+		r.promRegistry.SetTopics(r.topics.len())
+	})
+	if err != nil {
+		return err
+	}
+	if loaded {
 		return ErrTopicAlreadyExists
 	}
 
-	// Create new topic with configured ring buffer size
-	newTopic := topic.NewTopic(name, r.cfg.DefaultRingBufferSize)
-	r.topics[name] = newTopic
+	log.Printf("Created durable topic: %s", name)
+	return nil
+}
 
-	// Update metrics
-	r.metrics.IncTopics()
+// newTopic constructs a topic, opening a WAL-backed ring buffer under
+// cfg.WALDir when WAL persistence is enabled and a plain in-memory ring
+// buffer otherwise.
+func (r *Registry) newTopic(name string) (*topic.Topic, error) {
+	if r.cfg.WALDir == "" {
+		t := topic.NewTopic(name, r.cfg.DefaultRingBufferSize)
+		t.SetMetricsRegistry(r.promRegistry)
+		t.SetMessageHandler(r)
+		r.wireDropHandler(t, name)
+		return t, nil
+	}
+
+	ring, err := ringbuffer.NewRingBufferWithWALConfig(r.cfg.DefaultRingBufferSize, ringbuffer.WALConfig{
+		Dir:           r.walPath(name),
+		FsyncPolicy:   ringbuffer.FsyncPolicy(r.cfg.WALFsyncPolicy),
+		FsyncInterval: r.cfg.WALFsyncInterval,
+		Retention:     r.cfg.WALRetention,
+		MaxTotalBytes: r.cfg.WALMaxTotalBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := topic.NewTopicWithRing(name, ring)
+	t.SetMetricsRegistry(r.promRegistry)
+	t.SetMessageHandler(r)
+	r.wireDropHandler(t, name)
+	return t, nil
+}
+
+// wireDropHandler wires t's DropHandler to the registry's DLQ subsystem
+// (see dlq.go), capturing name so handleDrop knows which topic a drop
+// originated from without Topic needing to expose it itself.
+func (r *Registry) wireDropHandler(t *topic.Topic, name string) {
+	t.SetDropHandler(func(sub *subscriber.Subscriber, msg models.Message) {
+		r.handleDrop(name, sub, msg)
+	})
+}
+
+// MetricsRegistry returns the metrics.Registry resolved from
+// cfg.MetricsBackend, for mounting metrics.RegisterRoutes.
+func (r *Registry) MetricsRegistry() metrics.Registry {
+	return r.promRegistry
+}
+
+func (r *Registry) walPath(name string) string {
+	return filepath.Join(r.cfg.WALDir, name)
+}
+
+// RestoreFromWAL scans cfg.WALDir for per-topic WAL directories left behind
+// by a previous run and recreates those topics, replaying their ring
+// buffers from disk, so history and sequence numbers survive a restart. It
+// is a no-op when WAL persistence is disabled. Call it once, before serving
+// any traffic.
+func (r *Registry) RestoreFromWAL() error {
+	if r.cfg.WALDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(r.cfg.WALDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("registry: scan wal dir: %w", err)
+	}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		name := e.Name()
+
+		_, loaded, err := r.topics.loadOrCreate(name, func() (*topic.Topic, error) {
+			return r.newTopic(name)
+		}, func(*topic.Topic) {
+			r.metrics.IncTopics()
+			r.promRegistry.SetTopics(r.topics.len())
+		})
+		if err != nil {
+			return fmt.Errorf("registry: restore topic %q: %w", name, err)
+		}
+		if !loaded {
+			log.Printf("Restored topic from WAL: %s", name)
+		}
+	}
 
-	log.Printf("Created topic: %s", name)
 	return nil
 }
 
@@ -82,10 +378,9 @@ func (r *Registry) DeleteTopic(name string) error {
 		return ErrInvalidTopicName
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	topic, exists := r.topics[name]
+	// Atomically remove name so a concurrent DeleteTopic call for the same
+	// name can't also see it and double-close it.
+	topic, exists := r.topics.take(name)
 	if !exists {
 		return ErrTopicNotFound
 	}
@@ -114,11 +409,19 @@ func (r *Registry) DeleteTopic(name string) error {
 	// Close the topic (this will close all subscribers)
 	topic.Close()
 
-	// Remove from registry
-	delete(r.topics, name)
+	// Close and remove the topic's WAL, if it has one.
+	if err := topic.CloseRing(); err != nil {
+		log.Printf("Error closing ring buffer for topic %s: %v", name, err)
+	}
+	if r.cfg.WALDir != "" {
+		if err := os.RemoveAll(r.walPath(name)); err != nil {
+			log.Printf("Error removing WAL directory for topic %s: %v", name, err)
+		}
+	}
 
 	// Update metrics
 	r.metrics.DecTopics()
+	r.promRegistry.SetTopics(r.topics.len())
 
 	log.Printf("Deleted topic: %s (closed %d subscribers)", name, len(subscriberIDs))
 	return nil
@@ -131,73 +434,113 @@ func (r *Registry) GetTopic(name string) (*topic.Topic, bool) {
 		return nil, false
 	}
 
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	topic, exists := r.topics[name]
-	return topic, exists
+	return r.topics.get(name)
 }
 
-// ListTopics returns information about all topics in the registry.
+// ListTopics returns information about all topics in the registry,
+// gathered by ranging every shard of r.topics concurrently.
 func (r *Registry) ListTopics() []TopicInfo {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	topics := make([]TopicInfo, 0, len(r.topics))
-	for name, t := range r.topics {
-		topics = append(topics, TopicInfo{
-			Name:           name,
-			Subscribers:    t.GetSubscriberCount(),
-			Messages:       t.GetMessageCount(),
-			Dropped:        t.GetDroppedCount(),
-			RingBufferSize: t.GetRingBufferSize(),
+	var mu sync.Mutex
+	var topics []TopicInfo
+
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(k, v any) bool {
+			name := k.(string)
+			t := v.(*topic.Topic)
+			lastSeq, _ := t.LastSeq()
+			info := TopicInfo{
+				Name:           name,
+				Subscribers:    t.GetSubscriberCount(),
+				Messages:       t.GetMessageCount(),
+				Dropped:        t.GetDroppedCount(),
+				RingBufferSize: t.GetRingBufferSize(),
+				TTL:            t.GetTTL(),
+				LastActivity:   t.GetLastActivity().Format(time.RFC3339),
+				ExpiresAt:      expiresAt(t),
+				LastSeq:        lastSeq,
+			}
+			mu.Lock()
+			topics = append(topics, info)
+			mu.Unlock()
+			return true
 		})
-	}
+		return nil
+	})
 
+	if topics == nil {
+		topics = make([]TopicInfo, 0)
+	}
 	return topics
 }
 
-// Stats returns detailed statistics for all topics.
-func (r *Registry) Stats() map[string]TopicStats {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// expiresAt formats when t will become eligible for idle reaping, or ""
+// if it has no TTL set.
+func expiresAt(t *topic.Topic) string {
+	ttl := t.GetTTL()
+	if ttl <= 0 {
+		return ""
+	}
+	return t.GetLastActivity().Add(ttl).Format(time.RFC3339)
+}
 
+// Stats returns detailed statistics for all topics, gathered by ranging
+// every shard of r.topics concurrently.
+func (r *Registry) Stats() map[string]TopicStats {
+	var mu sync.Mutex
 	stats := make(map[string]TopicStats)
-	for name, t := range r.topics {
-		// Get metrics from metrics system
-		if topicMetrics := r.metrics.GetTopicMetrics(name); topicMetrics != nil {
-			stats[name] = TopicStats{
-				Name:           name,
-				Subscribers:    t.GetSubscriberCount(),
-				Messages:       t.GetMessageCount(),
-				Dropped:        t.GetDroppedCount(),
-				RingBufferSize: t.GetRingBufferSize(),
-			}
-		} else {
-			// Fallback to topic-only stats if metrics not available
-			stats[name] = TopicStats{
+
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(k, v any) bool {
+			name := k.(string)
+			t := v.(*topic.Topic)
+			s := TopicStats{
 				Name:           name,
 				Subscribers:    t.GetSubscriberCount(),
 				Messages:       t.GetMessageCount(),
 				Dropped:        t.GetDroppedCount(),
 				RingBufferSize: t.GetRingBufferSize(),
+				TTL:            t.GetTTL(),
+				LastActivity:   t.GetLastActivity().Format(time.RFC3339),
+				ExpiresAt:      expiresAt(t),
+				DeadLettered:   t.GetDeadLetteredCount(),
+				Retried:        t.GetRedeliveredCount(),
 			}
-		}
-	}
+			mu.Lock()
+			stats[name] = s
+			mu.Unlock()
+			return true
+		})
+		return nil
+	})
 
 	return stats
 }
 
 // PublishMessage publishes a message to a topic and updates metrics.
-// This is a convenience method that combines topic retrieval and publishing.
+// This is a convenience method that combines topic retrieval and
+// publishing. Beyond topicName's own subscribers, it also delivers to
+// every subscriber registered via SubscribePattern under a hierarchical
+// "+"/"#" pattern matching topicName (see TopicTrie), so a wildcard
+// subscription sees messages published to any topic it matches without
+// being added to that topic's own subscriber map.
 func (r *Registry) PublishMessage(topicName string, msg models.Message) (delivered int, dropped int, err error) {
-	topic, exists := r.GetTopic(topicName)
+	t, exists := r.GetTopic(topicName)
 	if !exists {
 		return 0, 0, ErrTopicNotFound
 	}
 
-	// Publish message using configured policy and buffer size
-	delivered, dropped = topic.Publish(msg, r.cfg.DefaultPublishPolicy, r.cfg.DefaultWSBufferSize)
+	// Publish message using the registry's configured default policy
+	delivered, dropped = t.Publish(msg, r.defaultPolicy)
+
+	// Deliver to subscribers matched via a hierarchical/wildcard pattern,
+	// outside any lock the trie or topic subscriber map holds.
+	for _, sub := range r.trie.Match(topicName) {
+		if t.DeliverTo(sub, msg, r.defaultPolicy) {
+			delivered++
+		} else {
+			dropped++
+		}
+	}
 
 	// Update metrics
 	r.metrics.IncDelivered(topicName, delivered)
@@ -206,8 +549,219 @@ func (r *Registry) PublishMessage(topicName string, msg models.Message) (deliver
 	return delivered, dropped, nil
 }
 
+// SubscribePattern registers sub to receive every message published to a
+// topic name matching pattern, an MQTT-style hierarchical pattern where
+// "+" matches exactly one "/"-separated segment and a trailing "#"
+// matches every remaining segment. Unlike a plain topic subscription
+// (Topic.AddSubscriber), sub is not tied to any single concrete topic:
+// PublishMessage consults the pattern trie for every publish. Returns
+// ErrInvalidTopicName if pattern is malformed (see TopicTrie).
+func (r *Registry) SubscribePattern(pattern string, sub *subscriber.Subscriber) error {
+	return r.trie.Subscribe(pattern, sub)
+}
+
+// UnsubscribePattern removes clientID's registration under pattern,
+// previously added by SubscribePattern.
+func (r *Registry) UnsubscribePattern(pattern, clientID string) {
+	r.trie.Unsubscribe(pattern, clientID)
+}
+
+// SubscribeFrom adds sub to topicName and replays every retained message
+// with Seq >= offset into sub.Send before returning, so a caller can start
+// forwarding sub.Send to a client without missing or duplicating messages
+// at the replay/live boundary. It mirrors the resume logic
+// subscriberService/http's handleSubscribe already applies for a
+// from/from_oldest subscribe: sub is added to the topic before the replay
+// snapshot is taken, so a message published concurrently with the replay
+// lands in both the snapshot and a live delivery; the returned
+// lastReplayed sequence lets the caller discard any live message with
+// Seq <= lastReplayed as the duplicate side of that race. Returns
+// ErrTopicNotFound if topicName doesn't exist.
+func (r *Registry) SubscribeFrom(topicName string, sub *subscriber.Subscriber, offset uint64) (lastReplayed uint64, err error) {
+	t, exists := r.GetTopic(topicName)
+	if !exists {
+		return 0, ErrTopicNotFound
+	}
+
+	t.AddSubscriber(sub)
+
+	history, err := t.ReplayFrom(offset)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range history {
+		m := m
+		select {
+		case sub.Send <- models.ServerMsg{Type: "message", Topic: topicName, Message: &m, Ts: time.Now()}:
+		default:
+			// Slow subscriber during replay: there's no OverflowPolicy to
+			// consult here, so drop the backlog entry rather than block
+			// the caller on startup.
+		}
+		if m.Seq > lastReplayed {
+			lastReplayed = m.Seq
+		}
+	}
+	return lastReplayed, nil
+}
+
+// PublishMessageAsync hands msg to topicName's publish Bundler and returns
+// a channel that receives exactly one PublishResult once the batch msg
+// ends up in is flushed, amortizing fan-out cost under high write rates
+// instead of delivering msg on its own right away (see PublishMessage).
+// The bundler is created lazily on first use and configured from
+// cfg.MaxBatchMessages, cfg.MaxBatchBytes, and cfg.MaxBatchDelay.
+// msg.Payload exceeding cfg.MaxMessageBytes is rejected up front with
+// ErrOversizedMessage, before ever reaching a bundler. The topic itself
+// need not exist yet; if it still doesn't when the batch flushes, every
+// request in that batch receives ErrTopicNotFound.
+func (r *Registry) PublishMessageAsync(topicName string, msg models.Message) <-chan PublishResult {
+	resultCh := make(chan PublishResult, 1)
+
+	if r.cfg.MaxMessageBytes > 0 && len(msg.Payload) > r.cfg.MaxMessageBytes {
+		resultCh <- PublishResult{Err: ErrOversizedMessage}
+		return resultCh
+	}
+
+	r.bundlersMu.Lock()
+	b, exists := r.bundlers[topicName]
+	if !exists {
+		b = newBundler(r, topicName)
+		r.bundlers[topicName] = b
+	}
+	r.bundlersMu.Unlock()
+
+	b.reqCh <- bundlerRequest{msg: msg, result: resultCh}
+	return resultCh
+}
+
+// ErrNoResponders is returned by Request when a message was published but
+// delivered to no subscribers, so no reply could ever arrive.
+var ErrNoResponders = fmt.Errorf("request: no subscribers to respond")
+
+// Request publishes payload to topicName as a correlated message and blocks
+// until a subscriber replies with a "response" frame carrying the same
+// correlation ID (see HandleClientMessage), or ctx is done. It turns the
+// normally one-way fanout into a request/response call suitable for
+// RPC-style workloads; a topic with more than one subscriber may see more
+// than one reply, of which Request returns only the first.
+func (r *Registry) Request(ctx context.Context, topicName string, payload json.RawMessage) (models.ServerMsg, error) {
+	correlationID := fmt.Sprintf("req-%s-%d", topicName, time.Now().UnixNano())
+
+	replyCh := make(chan models.ServerMsg, 1)
+	r.pendingMu.Lock()
+	r.pending[correlationID] = replyCh
+	r.pendingMu.Unlock()
+	defer func() {
+		r.pendingMu.Lock()
+		delete(r.pending, correlationID)
+		r.pendingMu.Unlock()
+	}()
+
+	msg := models.Message{
+		ID:         correlationID,
+		Payload:    payload,
+		Attributes: map[string]string{"correlation_id": correlationID},
+	}
+	delivered, _, err := r.PublishMessage(topicName, msg)
+	if err != nil {
+		return models.ServerMsg{}, err
+	}
+	if delivered == 0 {
+		return models.ServerMsg{}, ErrNoResponders
+	}
+
+	select {
+	case resp := <-replyCh:
+		return resp, nil
+	case <-ctx.Done():
+		return models.ServerMsg{}, ctx.Err()
+	}
+}
+
+// HandleClientMessage implements subscriber.MessageHandler. A "response"
+// frame is routed to the Request call waiting on its correlation ID
+// (carried in msg.RequestID); every other message type is ignored, since
+// ack/nack/modack are already handled by Subscriber itself before a frame
+// reaches here.
+func (r *Registry) HandleClientMessage(sub *subscriber.Subscriber, msg models.WSClientMsg) {
+	if msg.Type != "response" {
+		return
+	}
+	r.Respond(msg.RequestID, models.ServerMsg{
+		Type:      "response",
+		RequestID: msg.RequestID,
+		Message:   msg.Message,
+		Ts:        time.Now(),
+	})
+}
+
+// Respond delivers resp to the Request call waiting on correlationID, if
+// any. Returns whether a waiting call was found; a false return means the
+// correlation ID is unknown or its Request has already returned (e.g. its
+// ctx expired first).
+func (r *Registry) Respond(correlationID string, resp models.ServerMsg) bool {
+	r.pendingMu.Lock()
+	ch, ok := r.pending[correlationID]
+	r.pendingMu.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case ch <- resp:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReapAckTimeouts scans every topic for AckMode deliveries past their
+// acknowledgement deadline, redelivering them or dead-lettering them once
+// they exhaust their delivery attempts. It is a no-op unless the registry's
+// default policy is AckMode. Called periodically by the topic manager's
+// background reaper.
+func (r *Registry) ReapAckTimeouts() {
+	ackPolicy, ok := r.defaultPolicy.(*topic.AckModePolicy)
+	if !ok {
+		return
+	}
+
+	var mu sync.Mutex
+	var topics []*topic.Topic
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(_, v any) bool {
+			mu.Lock()
+			topics = append(topics, v.(*topic.Topic))
+			mu.Unlock()
+			return true
+		})
+		return nil
+	})
+
+	for _, t := range topics {
+		ackPolicy.RedeliverExpired(t, r.deadLetter)
+	}
+}
+
+// deadLetter publishes msg to topicName, creating it if needed. It is used
+// as the dead-letter sink for AckModePolicy, so dead-lettered messages are
+// delivered plainly (DropOldest) rather than re-entering ack semantics.
+func (r *Registry) deadLetter(topicName string, msg models.Message) {
+	t, err := r.GetOrCreateTopic(topicName)
+	if err != nil {
+		log.Printf("registry: dead-letter to %q: %v", topicName, err)
+		return
+	}
+	t.Publish(msg, topic.DropOldest())
+}
+
 // GetOrCreateTopic retrieves a topic by name, creating it if it doesn't exist.
-// This is useful for ensuring topics exist before publishing.
+// This is useful for ensuring topics exist before publishing. It is race-free
+// against a concurrent caller doing the same thing (or a concurrent
+// CreateTopic) for the same name: at most one of them actually creates the
+// topic, and every caller still returns that same topic rather than
+// ErrTopicAlreadyExists.
 func (r *Registry) GetOrCreateTopic(name string) (*topic.Topic, error) {
 	if name == "" {
 		return nil, ErrInvalidTopicName
@@ -218,49 +772,96 @@ func (r *Registry) GetOrCreateTopic(name string) (*topic.Topic, error) {
 		return topic, nil
 	}
 
-	// Create new topic
-	if err := r.CreateTopic(name); err != nil {
+	// Create new topic. A concurrent caller may have won the race between
+	// our GetTopic above and here; that's not an error from GetOrCreateTopic's
+	// point of view, so fall through to fetch whichever topic ended up
+	// registered.
+	if err := r.CreateTopic(name); err != nil && err != ErrTopicAlreadyExists {
 		return nil, err
 	}
 
-	// Return the newly created topic
-	topic, _ := r.GetTopic(name)
+	topic, exists := r.GetTopic(name)
+	if !exists {
+		return nil, ErrTopicNotFound
+	}
 	return topic, nil
 }
 
-// Close closes all topics and cleans up the registry.
+// Close closes all topics and cleans up the registry, including stopping
+// every topic's publish Bundler (flushing its partial batch first).
 func (r *Registry) Close() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.retry.close()
 
-	log.Printf("Closing registry with %d topics", len(r.topics))
+	r.bundlersMu.Lock()
+	bundlers := make([]*bundler, 0, len(r.bundlers))
+	for _, b := range r.bundlers {
+		bundlers = append(bundlers, b)
+	}
+	r.bundlers = make(map[string]*bundler)
+	r.bundlersMu.Unlock()
 
-	for name, t := range r.topics {
-		log.Printf("Closing topic: %s", name)
-		t.Close()
+	for _, b := range bundlers {
+		b.close()
 	}
 
-	// Clear topics map
-	r.topics = make(map[string]*topic.Topic)
+	log.Printf("Closing registry with %d topics", r.topics.len())
+
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(k, v any) bool {
+			log.Printf("Closing topic: %s", k.(string))
+			v.(*topic.Topic).Close()
+			return true
+		})
+		return nil
+	})
+
+	// Clear topics in place rather than reassigning r.topics: every other
+	// method reads that field unsynchronized, relying on the topicShardMap
+	// it points to staying put for the life of the Registry.
+	r.topics.clear()
 
 	log.Printf("Registry closed")
 }
 
 // GetTopicCount returns the total number of topics in the registry.
 func (r *Registry) GetTopicCount() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return len(r.topics)
+	return r.topics.len()
+}
+
+// ExpiredTopics returns the names of all topics that have a TTL set, have
+// had no activity for longer than that TTL as of now, and have no active
+// subscribers. It is used by the topic manager's background reaper to find
+// idle topics to delete.
+func (r *Registry) ExpiredTopics(now time.Time) []string {
+	var mu sync.Mutex
+	var expired []string
+
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(k, v any) bool {
+			if v.(*topic.Topic).IsExpired(now) {
+				mu.Lock()
+				expired = append(expired, k.(string))
+				mu.Unlock()
+			}
+			return true
+		})
+		return nil
+	})
+
+	return expired
 }
 
 // GetTotalSubscriberCount returns the total number of subscribers across all topics.
 func (r *Registry) GetTotalSubscriberCount() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	var total int64
 
-	total := 0
-	for _, t := range r.topics {
-		total += t.GetSubscriberCount()
-	}
-	return total
+	r.topics.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(_, v any) bool {
+			atomic.AddInt64(&total, int64(v.(*topic.Topic).GetSubscriberCount()))
+			return true
+		})
+		return nil
+	})
+
+	return int(total)
 }
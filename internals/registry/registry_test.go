@@ -1,13 +1,19 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/ringbuffer"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
 )
 
 func TestNewRegistry(t *testing.T) {
@@ -56,6 +62,26 @@ func TestRegistry_CreateTopic(t *testing.T) {
 	}
 }
 
+func TestRegistry_ExpiredTopics(t *testing.T) {
+	cfg := config.NewConfig()
+	metrics := metrics.NewMetrics()
+	registry := NewRegistry(cfg, metrics)
+
+	if err := registry.CreateTopicWithTTL("idle-topic", time.Millisecond); err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+	if err := registry.CreateTopic("no-ttl-topic"); err != nil {
+		t.Fatalf("Failed to create topic: %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	expired := registry.ExpiredTopics(time.Now())
+	if len(expired) != 1 || expired[0] != "idle-topic" {
+		t.Errorf("Expected only idle-topic to be expired, got %v", expired)
+	}
+}
+
 func TestRegistry_DeleteTopic(t *testing.T) {
 	cfg := config.NewConfig()
 	metrics := metrics.NewMetrics()
@@ -246,6 +272,299 @@ func TestRegistry_PublishMessage(t *testing.T) {
 	}
 }
 
+func TestRegistry_PublishMessageAsync(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxBatchMessages = 3
+	cfg.MaxBatchDelay = 50 * time.Millisecond
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	if err := registry.CreateTopic("batched"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	msg := models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)}
+
+	var results []<-chan PublishResult
+	for i := 0; i < 3; i++ {
+		results = append(results, registry.PublishMessageAsync("batched", msg))
+	}
+
+	for i, ch := range results {
+		select {
+		case res := <-ch:
+			if res.Err != nil {
+				t.Errorf("result %d: unexpected error %v", i, res.Err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("result %d: PublishMessageAsync never flushed", i)
+		}
+	}
+
+	top, _ := registry.GetTopic("batched")
+	if top.GetMessageCount() != 3 {
+		t.Errorf("expected 3 published messages, got %d", top.GetMessageCount())
+	}
+}
+
+func TestRegistry_PublishMessageAsync_FlushesOnDelay(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxBatchMessages = 100
+	cfg.MaxBatchDelay = 10 * time.Millisecond
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	if err := registry.CreateTopic("idle-batch"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	ch := registry.PublishMessageAsync("idle-batch", models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			t.Errorf("unexpected error %v", res.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PublishMessageAsync never flushed on MaxBatchDelay")
+	}
+}
+
+func TestRegistry_PublishMessageAsync_OversizedMessage(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxMessageBytes = 4
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	ch := registry.PublishMessageAsync("any-topic", models.Message{ID: "msg-1", Payload: json.RawMessage(`{"too":"big"}`)})
+
+	res := <-ch
+	if res.Err != ErrOversizedMessage {
+		t.Errorf("expected ErrOversizedMessage, got %v", res.Err)
+	}
+}
+
+func TestRegistry_PublishMessageAsync_UnknownTopic(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxBatchMessages = 1
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	ch := registry.PublishMessageAsync("missing", models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)})
+
+	select {
+	case res := <-ch:
+		if res.Err != ErrTopicNotFound {
+			t.Errorf("expected ErrTopicNotFound, got %v", res.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PublishMessageAsync never flushed")
+	}
+}
+
+func TestRegistry_PublishMessageAsync_DeliversToPatternSubscriber(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.MaxBatchMessages = 1
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	if err := registry.CreateTopic("sensors/room1/temperature"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	if err := registry.SubscribePattern("sensors/+/temperature", sub); err != nil {
+		t.Fatalf("SubscribePattern: %v", err)
+	}
+
+	msg := models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)}
+	ch := registry.PublishMessageAsync("sensors/room1/temperature", msg)
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			t.Fatalf("unexpected error %v", res.Err)
+		}
+		if res.Delivered != 1 {
+			t.Errorf("expected delivered=1, got delivered=%d", res.Delivered)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("PublishMessageAsync never flushed")
+	}
+
+	select {
+	case got := <-sub.Send:
+		if got.Message == nil || got.Message.ID != "msg-1" {
+			t.Errorf("expected to receive msg-1, got %+v", got)
+		}
+	default:
+		t.Fatal("expected the pattern-matched subscriber to receive a message")
+	}
+}
+
+func TestRegistry_PublishMessage_DeliversToPatternSubscriber(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	if err := registry.CreateTopic("sensors/room1/temperature"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	if err := registry.SubscribePattern("sensors/+/temperature", sub); err != nil {
+		t.Fatalf("SubscribePattern: %v", err)
+	}
+
+	msg := models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)}
+	delivered, dropped, err := registry.PublishMessage("sensors/room1/temperature", msg)
+	if err != nil {
+		t.Fatalf("PublishMessage: %v", err)
+	}
+	if delivered != 1 || dropped != 0 {
+		t.Errorf("expected delivered=1, dropped=0, got delivered=%d, dropped=%d", delivered, dropped)
+	}
+
+	select {
+	case got := <-sub.Send:
+		if got.Message == nil || got.Message.ID != "msg-1" {
+			t.Errorf("expected to receive msg-1, got %+v", got)
+		}
+	default:
+		t.Fatal("expected the pattern-matched subscriber to receive a message")
+	}
+}
+
+func TestRegistry_PublishMessage_UnsubscribePatternStopsDelivery(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	defer registry.Close()
+
+	if err := registry.CreateTopic("sensors/room1/temperature"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	if err := registry.SubscribePattern("sensors/#", sub); err != nil {
+		t.Fatalf("SubscribePattern: %v", err)
+	}
+	registry.UnsubscribePattern("sensors/#", "client-1")
+
+	msg := models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)}
+	delivered, _, err := registry.PublishMessage("sensors/room1/temperature", msg)
+	if err != nil {
+		t.Fatalf("PublishMessage: %v", err)
+	}
+	if delivered != 0 {
+		t.Errorf("expected no delivery after UnsubscribePattern, got delivered=%d", delivered)
+	}
+}
+
+func TestRegistry_Request_NoResponders(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("rpc"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := registry.Request(ctx, "rpc", json.RawMessage(`{"q":1}`)); err != ErrNoResponders {
+		t.Errorf("expected ErrNoResponders, got %v", err)
+	}
+}
+
+func TestRegistry_Request_ReceivesResponse(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("rpc"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	top, _ := registry.GetTopic("rpc")
+	top.AddSubscriber(subscriber.NewSubscriber("client-1", nil, 10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	type result struct {
+		resp models.ServerMsg
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := registry.Request(ctx, "rpc", json.RawMessage(`{"q":1}`))
+		done <- result{resp, err}
+	}()
+
+	// Request hasn't exposed its correlation ID yet, so poll its pending
+	// map (same package, so this is a white-box wait) until it registers.
+	var correlationID string
+	for correlationID == "" {
+		registry.pendingMu.Lock()
+		for id := range registry.pending {
+			correlationID = id
+		}
+		registry.pendingMu.Unlock()
+	}
+
+	reply := models.ServerMsg{
+		Type:      "response",
+		RequestID: correlationID,
+		Message:   &models.Message{Payload: json.RawMessage(`{"a":2}`)},
+	}
+	if !registry.Respond(correlationID, reply) {
+		t.Fatal("Respond: expected a waiting Request call")
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Request: %v", res.err)
+	}
+	if string(res.resp.Message.Payload) != `{"a":2}` {
+		t.Errorf("expected response payload {\"a\":2}, got %s", res.resp.Message.Payload)
+	}
+}
+
+func TestRegistry_HandleClientMessage_RoutesResponse(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	replyCh := make(chan models.ServerMsg, 1)
+	registry.pendingMu.Lock()
+	registry.pending["req-1"] = replyCh
+	registry.pendingMu.Unlock()
+
+	registry.HandleClientMessage(nil, models.WSClientMsg{
+		Type:      "response",
+		RequestID: "req-1",
+		Message:   &models.Message{Payload: json.RawMessage(`{"ok":true}`)},
+	})
+
+	select {
+	case resp := <-replyCh:
+		if string(resp.Message.Payload) != `{"ok":true}` {
+			t.Errorf("expected routed payload {\"ok\":true}, got %s", resp.Message.Payload)
+		}
+	default:
+		t.Fatal("expected HandleClientMessage to deliver a reply")
+	}
+
+	// A non-"response" message type is ignored.
+	registry.pendingMu.Lock()
+	registry.pending["req-2"] = make(chan models.ServerMsg, 1)
+	registry.pendingMu.Unlock()
+	registry.HandleClientMessage(nil, models.WSClientMsg{Type: "ping", RequestID: "req-2"})
+	registry.pendingMu.Lock()
+	_, stillPending := registry.pending["req-2"]
+	registry.pendingMu.Unlock()
+	if !stillPending {
+		t.Error("expected a non-response message type to leave req-2 untouched")
+	}
+}
+
 func TestRegistry_GetOrCreateTopic(t *testing.T) {
 	cfg := config.NewConfig()
 	metrics := metrics.NewMetrics()
@@ -348,3 +667,364 @@ func TestRegistry_Concurrency(t *testing.T) {
 		t.Errorf("Expected 10 topics, got %d", registry.GetTopicCount())
 	}
 }
+
+func TestRegistry_RestoreFromWAL(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.WALDir = t.TempDir()
+
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	if err := registry.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	ordersTopic, _ := registry.GetTopic("orders")
+	ordersTopic.Publish(models.Message{ID: "1", Payload: json.RawMessage(`{}`)}, topic.DropOldest())
+	if err := ordersTopic.CloseRing(); err != nil {
+		t.Fatalf("CloseRing failed: %v", err)
+	}
+
+	// A fresh registry, as if the process restarted, should rebuild the
+	// topic from the WAL directory left behind on disk.
+	restored := NewRegistry(cfg, metrics.NewMetrics())
+	if err := restored.RestoreFromWAL(); err != nil {
+		t.Fatalf("RestoreFromWAL failed: %v", err)
+	}
+
+	restoredTopic, exists := restored.GetTopic("orders")
+	if !exists {
+		t.Fatal("expected topic 'orders' to be restored from WAL")
+	}
+	if seq, ok := restoredTopic.LastSeq(); !ok || seq != 1 {
+		t.Errorf("expected restored topic's LastSeq to be 1, got %d, ok=%v", seq, ok)
+	}
+}
+
+func TestRegistry_CreateTopic_AppliesDefaultTopicTTL(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DefaultTopicTTL = time.Minute
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("chat-room"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	tp, _ := registry.GetTopic("chat-room")
+	if tp.GetTTL() != time.Minute {
+		t.Errorf("expected CreateTopic to apply cfg.DefaultTopicTTL, got TTL %v", tp.GetTTL())
+	}
+}
+
+func TestRegistry_CreateTopicWithOptions(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DefaultTopicTTL = time.Minute
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopicWithOptions("explicit-ttl", TopicOptions{TTL: 5 * time.Second}); err != nil {
+		t.Fatalf("CreateTopicWithOptions failed: %v", err)
+	}
+	if err := registry.CreateTopicWithOptions("falls-back-to-default", TopicOptions{}); err != nil {
+		t.Fatalf("CreateTopicWithOptions failed: %v", err)
+	}
+
+	explicit, _ := registry.GetTopic("explicit-ttl")
+	if explicit.GetTTL() != 5*time.Second {
+		t.Errorf("expected explicit TTL to be honored, got %v", explicit.GetTTL())
+	}
+
+	fallback, _ := registry.GetTopic("falls-back-to-default")
+	if fallback.GetTTL() != time.Minute {
+		t.Errorf("expected a zero opts.TTL to fall back to cfg.DefaultTopicTTL, got %v", fallback.GetTTL())
+	}
+}
+
+func TestRegistry_ListTopics_ExpiresAt(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopicWithTTL("idle-topic", time.Minute); err != nil {
+		t.Fatalf("CreateTopicWithTTL failed: %v", err)
+	}
+	if err := registry.CreateTopic("no-ttl-topic"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+
+	topics := make(map[string]TopicInfo)
+	for _, info := range registry.ListTopics() {
+		topics[info.Name] = info
+	}
+
+	if topics["idle-topic"].ExpiresAt == "" {
+		t.Error("expected idle-topic to have a non-empty ExpiresAt")
+	}
+	if topics["no-ttl-topic"].ExpiresAt != "" {
+		t.Errorf("expected no-ttl-topic to have an empty ExpiresAt, got %q", topics["no-ttl-topic"].ExpiresAt)
+	}
+}
+
+func TestRegistry_CreateDurableTopic_RequiresWALDir(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateDurableTopic("orders", DurableOpts{}); err != ErrWALNotConfigured {
+		t.Errorf("expected ErrWALNotConfigured, got %v", err)
+	}
+}
+
+func TestRegistry_CreateDurableTopic_SurvivesRestart(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.WALDir = t.TempDir()
+
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+	if err := registry.CreateDurableTopic("orders", DurableOpts{FsyncPolicy: ringbuffer.FsyncAlways}); err != nil {
+		t.Fatalf("CreateDurableTopic failed: %v", err)
+	}
+
+	ordersTopic, _ := registry.GetTopic("orders")
+	ordersTopic.Publish(models.Message{ID: "1", Payload: json.RawMessage(`{}`)}, topic.DropOldest())
+	if err := ordersTopic.CloseRing(); err != nil {
+		t.Fatalf("CloseRing failed: %v", err)
+	}
+
+	restored := NewRegistry(cfg, metrics.NewMetrics())
+	if err := restored.RestoreFromWAL(); err != nil {
+		t.Fatalf("RestoreFromWAL failed: %v", err)
+	}
+
+	restoredTopic, exists := restored.GetTopic("orders")
+	if !exists {
+		t.Fatal("expected topic 'orders' to be restored from WAL")
+	}
+	if seq, ok := restoredTopic.LastSeq(); !ok || seq != 1 {
+		t.Errorf("expected restored topic's LastSeq to be 1, got %d, ok=%v", seq, ok)
+	}
+}
+
+func TestRegistry_SubscribeFrom_ReplaysThenReturnsLastSeq(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	top, _ := registry.GetTopic("orders")
+	for i := 1; i <= 3; i++ {
+		top.Publish(models.Message{ID: fmt.Sprintf("msg-%d", i), Payload: json.RawMessage(`{}`)}, topic.DropOldest())
+	}
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	lastReplayed, err := registry.SubscribeFrom("orders", sub, 1)
+	if err != nil {
+		t.Fatalf("SubscribeFrom failed: %v", err)
+	}
+	if lastReplayed != 3 {
+		t.Errorf("expected lastReplayed=3, got %d", lastReplayed)
+	}
+	if len(sub.Send) != 3 {
+		t.Errorf("expected 3 replayed messages buffered on sub.Send, got %d", len(sub.Send))
+	}
+
+	// SubscribeFrom must have added sub to the topic so it keeps receiving
+	// messages published after the replay.
+	top.Publish(models.Message{ID: "msg-4", Payload: json.RawMessage(`{}`)}, topic.DropOldest())
+	if len(sub.Send) != 4 {
+		t.Errorf("expected a live publish after SubscribeFrom to reach sub.Send, got %d buffered", len(sub.Send))
+	}
+}
+
+func TestRegistry_SubscribeFrom_UnknownTopic(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	if _, err := registry.SubscribeFrom("missing", sub, 0); err != ErrTopicNotFound {
+		t.Errorf("expected ErrTopicNotFound, got %v", err)
+	}
+}
+
+func TestRegistry_PublishMessage_DropWithoutRetryGoesToDefaultDLQ(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DefaultPublishPolicy = "DROP_NEWEST"
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	ordersTopic, _ := registry.GetTopic("orders")
+	ordersTopic.AddSubscriber(subscriber.NewSubscriber("client-1", nil, 1))
+
+	if _, _, err := registry.PublishMessage("orders", models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("first publish failed: %v", err)
+	}
+	delivered, dropped, err := registry.PublishMessage("orders", models.Message{ID: "msg-2", Payload: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatalf("second publish failed: %v", err)
+	}
+	if delivered != 0 || dropped != 1 {
+		t.Fatalf("second publish: delivered=%d dropped=%d, want 0/1", delivered, dropped)
+	}
+
+	dlqTopic, exists := registry.GetTopic("__dlq.orders")
+	if !exists {
+		t.Fatal("expected \"__dlq.orders\" to be auto-created")
+	}
+	last := dlqTopic.GetLastN(1)
+	if len(last) != 1 {
+		t.Fatalf("expected 1 dead-lettered message, got %d", len(last))
+	}
+	var dl models.DeadLetter
+	if err := json.Unmarshal(last[0].Payload, &dl); err != nil {
+		t.Fatalf("unmarshal DeadLetter: %v", err)
+	}
+	if dl.OriginalTopic != "orders" || dl.ClientID != "client-1" || dl.Message.ID != "msg-2" || dl.Reason != "buffer_overflow" {
+		t.Errorf("unexpected DeadLetter: %+v", dl)
+	}
+
+	stats := registry.Stats()["orders"]
+	if stats.DeadLettered != 1 {
+		t.Errorf("expected orders.DeadLettered=1, got %d", stats.DeadLettered)
+	}
+}
+
+func TestRegistry_PublishMessage_RetryRedeliversOnceRoomFrees(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DefaultPublishPolicy = "DROP_NEWEST"
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	if err := registry.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	ordersTopic, _ := registry.GetTopic("orders")
+	sub := subscriber.NewSubscriber("client-1", nil, 1)
+	sub.SetRetryPolicy(subscriber.RetryPolicy{MaxAttempts: 5, Backoff: 5 * time.Millisecond, Strategy: subscriber.RetryFixed})
+	ordersTopic.AddSubscriber(sub)
+
+	if _, _, err := registry.PublishMessage("orders", models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("first publish failed: %v", err)
+	}
+	if _, dropped, err := registry.PublishMessage("orders", models.Message{ID: "msg-2", Payload: json.RawMessage(`{}`)}); err != nil || dropped != 1 {
+		t.Fatalf("second publish: dropped=%d err=%v, want dropped=1", dropped, err)
+	}
+
+	// Drain the buffer so the scheduled retry has room to succeed.
+	<-sub.Send
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if registry.Stats()["orders"].Retried == 1 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stats := registry.Stats()["orders"]
+	if stats.Retried != 1 {
+		t.Fatalf("expected orders.Retried=1 after redelivery, got %d", stats.Retried)
+	}
+	if stats.DeadLettered != 0 {
+		t.Errorf("expected no dead-lettering on a successful retry, got %d", stats.DeadLettered)
+	}
+	if len(sub.Send) != 1 {
+		t.Errorf("expected the redelivered message to land on sub.Send, got %d buffered", len(sub.Send))
+	}
+}
+
+func TestRegistry_SetDLQPolicy_CustomTopic(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.DefaultPublishPolicy = "DROP_NEWEST"
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	registry.SetDLQPolicy("orders", DLQPolicy{Topic: "ops-review"})
+
+	if err := registry.CreateTopic("orders"); err != nil {
+		t.Fatalf("CreateTopic failed: %v", err)
+	}
+	ordersTopic, _ := registry.GetTopic("orders")
+	ordersTopic.AddSubscriber(subscriber.NewSubscriber("client-1", nil, 1))
+
+	registry.PublishMessage("orders", models.Message{ID: "msg-1", Payload: json.RawMessage(`{}`)})
+	registry.PublishMessage("orders", models.Message{ID: "msg-2", Payload: json.RawMessage(`{}`)})
+
+	if _, exists := registry.GetTopic("ops-review"); !exists {
+		t.Fatal("expected DLQPolicy.Topic \"ops-review\" to be used instead of the default")
+	}
+	if _, exists := registry.GetTopic("__dlq.orders"); exists {
+		t.Error("expected the default DLQ topic not to be created when DLQPolicy.Topic is set")
+	}
+}
+
+func TestRegistry_CreateTopic_RaceFreeAgainstGetOrCreateTopic(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	const n = 20
+	errs := make(chan error, n)
+	topics := make(chan *topic.Topic, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				errs <- registry.CreateTopic("shared-topic")
+			} else {
+				t, err := registry.GetOrCreateTopic("shared-topic")
+				errs <- err
+				topics <- t
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(topics)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+		} else if err != ErrTopicAlreadyExists {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if created == 0 {
+		t.Error("expected at least one caller to successfully create or retrieve the topic")
+	}
+	if registry.GetTopicCount() != 1 {
+		t.Errorf("expected 1 topic, got %d", registry.GetTopicCount())
+	}
+
+	var first *topic.Topic
+	for got := range topics {
+		if first == nil {
+			first = got
+			continue
+		}
+		if got != first {
+			t.Error("expected every GetOrCreateTopic caller to observe the same *topic.Topic instance")
+		}
+	}
+}
+
+// TestRegistry_Close_RaceFreeAgainstCreateTopic guards against Close
+// reassigning r.topics to a fresh topicShardMap, which every other method
+// reads unsynchronized: run with -race, that used to trip a data race
+// between Close and a concurrent CreateTopic/DeleteTopic.
+func TestRegistry_Close_RaceFreeAgainstCreateTopic(t *testing.T) {
+	cfg := config.NewConfig()
+	registry := NewRegistry(cfg, metrics.NewMetrics())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			name := fmt.Sprintf("topic-%d", i)
+			registry.CreateTopic(name)
+			registry.DeleteTopic(name)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		registry.Close()
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+)
+
+// defaultTopicShards is how many shards a topicShardMap uses when the
+// registry isn't configured with a different (power-of-two) count. See
+// config.Config.TopicShards.
+const defaultTopicShards = 32
+
+// topicShard is one bucket of a topicShardMap: a sync.Map holding this
+// shard's slice of topics, plus a dedicated lock so CreateTopic and
+// GetOrCreateTopic can check-and-create atomically against each other
+// without the sync.Map itself serializing unrelated shards.
+type topicShard struct {
+	m        sync.Map // name -> *topic.Topic
+	createMu sync.Mutex
+}
+
+// topicShardMap replaces a single map[string]*topic.Topic guarded by one
+// RWMutex with N independently-locked shards keyed by fnv32(name)&(N-1),
+// so GetTopic and PublishMessage's hot path never contends with unrelated
+// topics the way a global read lock would under many goroutines.
+type topicShardMap struct {
+	shards []*topicShard
+	mask   uint32
+}
+
+// newTopicShardMap creates a topicShardMap with n shards, rounded up to
+// the next power of two. n <= 0 substitutes defaultTopicShards.
+func newTopicShardMap(n int) *topicShardMap {
+	if n <= 0 {
+		n = defaultTopicShards
+	}
+	n = nextPowerOfTwo(n)
+
+	shards := make([]*topicShard, n)
+	for i := range shards {
+		shards[i] = &topicShard{}
+	}
+	return &topicShardMap{shards: shards, mask: uint32(n - 1)}
+}
+
+// nextPowerOfTwo returns the smallest power of two >= n (n > 0).
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// shardFor returns the shard responsible for name.
+func (sm *topicShardMap) shardFor(name string) *topicShard {
+	h := fnv.New32()
+	h.Write([]byte(name))
+	return sm.shards[h.Sum32()&sm.mask]
+}
+
+// get returns the topic registered under name, if any.
+func (sm *topicShardMap) get(name string) (*topic.Topic, bool) {
+	v, ok := sm.shardFor(name).m.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(*topic.Topic), true
+}
+
+// delete removes name from its shard.
+func (sm *topicShardMap) delete(name string) {
+	sm.shardFor(name).m.Delete(name)
+}
+
+// take atomically removes and returns the topic registered under name, if
+// any, so a caller can't race a concurrent take/loadOrCreate for the same
+// name into double-deleting (e.g. double-closing) it.
+func (sm *topicShardMap) take(name string) (*topic.Topic, bool) {
+	v, ok := sm.shardFor(name).m.LoadAndDelete(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(*topic.Topic), true
+}
+
+// loadOrCreate returns the topic already registered under name, if any
+// (loaded == true). Otherwise it calls create to build one, stores it, and
+// calls onCreated with it before returning (loaded == false). The whole
+// check-then-create sequence runs under name's shard lock, so a
+// CreateTopic racing a concurrent GetOrCreateTopic for the same name can't
+// both create a topic or double-count onCreated's metrics bump.
+func (sm *topicShardMap) loadOrCreate(name string, create func() (*topic.Topic, error), onCreated func(*topic.Topic)) (t *topic.Topic, loaded bool, err error) {
+	s := sm.shardFor(name)
+
+	if v, ok := s.m.Load(name); ok {
+		return v.(*topic.Topic), true, nil
+	}
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	if v, ok := s.m.Load(name); ok {
+		return v.(*topic.Topic), true, nil
+	}
+
+	t, err = create()
+	if err != nil {
+		return nil, false, err
+	}
+	s.m.Store(name, t)
+	if onCreated != nil {
+		onCreated(t)
+	}
+	return t, false, nil
+}
+
+// len returns the total number of topics across all shards.
+func (sm *topicShardMap) len() int {
+	total := 0
+	for _, s := range sm.shards {
+		s.m.Range(func(_, _ any) bool {
+			total++
+			return true
+		})
+	}
+	return total
+}
+
+// clear removes every topic from every shard in place. Unlike replacing the
+// *topicShardMap itself, this leaves the Registry's topics field pointing at
+// the same topicShardMap, so it's safe to call concurrently with GetTopic,
+// CreateTopic, etc., which read that field without a lock of their own.
+func (sm *topicShardMap) clear() {
+	for _, s := range sm.shards {
+		s.m.Range(func(k, _ any) bool {
+			s.m.Delete(k)
+			return true
+		})
+	}
+}
+
+// rangeShards calls fn once per shard concurrently (via an errgroup),
+// passing that shard's sync.Map so fn can Range over just its slice of
+// topics. It's the building block ListTopics, Stats, and
+// GetTotalSubscriberCount build on to merge per-shard results
+// without a global lock. fn's error, if any, is returned once every shard
+// has finished.
+func (sm *topicShardMap) rangeShards(fn func(*sync.Map) error) error {
+	g, _ := errgroup.WithContext(context.Background())
+	for _, s := range sm.shards {
+		s := s
+		g.Go(func() error {
+			return fn(&s.m)
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,110 @@
+package registry
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+)
+
+// benchGoroutines is the target concurrency for the 90/10 read-write mix
+// benchmarks below; b.SetParallelism is expressed as a multiplier of
+// GOMAXPROCS, so the multiplier is rounded up to reach at least this many.
+const benchGoroutines = 100
+
+// mapMutexTopics is the map[string]*topic.Topic + sync.RWMutex lookup
+// topicShardMap replaced, kept here only as a baseline to benchmark
+// against so BenchmarkTopicLookup_* below can show the throughput win.
+type mapMutexTopics struct {
+	mu sync.RWMutex
+	m  map[string]*topic.Topic
+}
+
+func newMapMutexTopics(n int) *mapMutexTopics {
+	return &mapMutexTopics{m: make(map[string]*topic.Topic, n)}
+}
+
+func (t *mapMutexTopics) get(name string) (*topic.Topic, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	v, ok := t.m[name]
+	return v, ok
+}
+
+func (t *mapMutexTopics) set(name string, top *topic.Topic) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.m[name] = top
+}
+
+// benchTopicNames returns n distinct topic names for the lookup benchmarks.
+func benchTopicNames(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("bench-topic-%d", i)
+	}
+	return names
+}
+
+// benchRunParallel drives get against 90% of ops and set against the
+// remaining 10%, split across at least benchGoroutines concurrent
+// goroutines, so the two
+// benchmarks below exercise the same 90/10 read-write mix.
+func benchRunParallel(b *testing.B, names []string, get func(string) (*topic.Topic, bool), set func(string, *topic.Topic)) {
+	procs := runtime.GOMAXPROCS(0)
+	if procs < 1 {
+		procs = 1
+	}
+	b.SetParallelism((benchGoroutines + procs - 1) / procs)
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+		top := topic.NewTopic("bench", 1)
+		for pb.Next() {
+			name := names[rnd.Intn(len(names))]
+			if rnd.Intn(10) == 0 {
+				set(name, top)
+			} else {
+				get(name)
+			}
+		}
+	})
+}
+
+// BenchmarkTopicLookup_MapMutex benchmarks the map[string]*topic.Topic +
+// sync.RWMutex lookup topicShardMap replaced, under a 90/10 read-write mix
+// over 10k topics and ~100 concurrent goroutines.
+func BenchmarkTopicLookup_MapMutex(b *testing.B) {
+	const topicCount = 10000
+	names := benchTopicNames(topicCount)
+	topics := newMapMutexTopics(topicCount)
+	for _, name := range names {
+		topics.set(name, topic.NewTopic(name, 1))
+	}
+
+	b.ResetTimer()
+	benchRunParallel(b, names, topics.get, topics.set)
+}
+
+// BenchmarkTopicLookup_Sharded benchmarks topicShardMap under the same
+// 90/10 read-write mix over 10k topics and ~100 concurrent goroutines, to
+// compare against BenchmarkTopicLookup_MapMutex.
+func BenchmarkTopicLookup_Sharded(b *testing.B) {
+	const topicCount = 10000
+	names := benchTopicNames(topicCount)
+	shards := newTopicShardMap(defaultTopicShards)
+	for _, name := range names {
+		n := name
+		shards.loadOrCreate(n, func() (*topic.Topic, error) {
+			return topic.NewTopic(n, 1), nil
+		}, nil)
+	}
+
+	b.ResetTimer()
+	benchRunParallel(b, names, shards.get, func(name string, top *topic.Topic) {
+		shards.shardFor(name).m.Store(name, top)
+	})
+}
@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+)
+
+func TestNewTopicShardMap_RoundsUpToPowerOfTwo(t *testing.T) {
+	cases := []struct {
+		n    int
+		want int
+	}{
+		{0, defaultTopicShards},
+		{-1, defaultTopicShards},
+		{1, 1},
+		{5, 8},
+		{32, 32},
+		{33, 64},
+	}
+	for _, c := range cases {
+		sm := newTopicShardMap(c.n)
+		if got := len(sm.shards); got != c.want {
+			t.Errorf("newTopicShardMap(%d): got %d shards, want %d", c.n, got, c.want)
+		}
+	}
+}
+
+func TestTopicShardMap_LoadOrCreate_OnlyCreatesOnce(t *testing.T) {
+	sm := newTopicShardMap(4)
+
+	var mu sync.Mutex
+	creates := 0
+	create := func() (*topic.Topic, error) {
+		mu.Lock()
+		creates++
+		mu.Unlock()
+		return topic.NewTopic("shared", 1), nil
+	}
+
+	const n = 20
+	var wg sync.WaitGroup
+	results := make([]*topic.Topic, n)
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t, _, _ := sm.loadOrCreate("shared", create, nil)
+			results[i] = t
+		}()
+	}
+	wg.Wait()
+
+	if creates != 1 {
+		t.Errorf("expected create to run exactly once, ran %d times", creates)
+	}
+	for i, got := range results {
+		if got != results[0] {
+			t.Errorf("result[%d] = %p, want the same topic as result[0] = %p", i, got, results[0])
+		}
+	}
+}
+
+func TestTopicShardMap_LoadOrCreate_PropagatesCreateError(t *testing.T) {
+	sm := newTopicShardMap(4)
+	wantErr := errors.New("boom")
+
+	_, _, err := sm.loadOrCreate("broken", func() (*topic.Topic, error) {
+		return nil, wantErr
+	}, nil)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("loadOrCreate() error = %v, want %v", err, wantErr)
+	}
+	if _, exists := sm.get("broken"); exists {
+		t.Error("expected a failed create not to leave a partial entry in the shard")
+	}
+}
+
+func TestTopicShardMap_TakeIsAtomic(t *testing.T) {
+	sm := newTopicShardMap(4)
+	sm.loadOrCreate("orders", func() (*topic.Topic, error) {
+		return topic.NewTopic("orders", 1), nil
+	}, nil)
+
+	const n = 10
+	var wg sync.WaitGroup
+	takes := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok := sm.take("orders")
+			takes <- ok
+		}()
+	}
+	wg.Wait()
+	close(takes)
+
+	successes := 0
+	for ok := range takes {
+		if ok {
+			successes++
+		}
+	}
+	if successes != 1 {
+		t.Errorf("expected exactly one take to succeed, got %d", successes)
+	}
+}
+
+func TestTopicShardMap_RangeShardsCoversEveryEntry(t *testing.T) {
+	sm := newTopicShardMap(8)
+	names := []string{"a", "b", "c", "d", "e"}
+	for _, name := range names {
+		name := name
+		sm.loadOrCreate(name, func() (*topic.Topic, error) {
+			return topic.NewTopic(name, 1), nil
+		}, nil)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	sm.rangeShards(func(shard *sync.Map) error {
+		shard.Range(func(k, _ any) bool {
+			mu.Lock()
+			seen[k.(string)] = true
+			mu.Unlock()
+			return true
+		})
+		return nil
+	})
+
+	if len(seen) != len(names) {
+		t.Errorf("rangeShards saw %d topics, want %d", len(seen), len(names))
+	}
+	if sm.len() != len(names) {
+		t.Errorf("len() = %d, want %d", sm.len(), len(names))
+	}
+}
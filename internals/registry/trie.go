@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+)
+
+// trieNode is one path segment of a registered subscription pattern.
+// "sensors/+/temperature" walks a node for "sensors", a child keyed "+"
+// (the literal wildcard character, not a real segment name), and a
+// "temperature" child under that. children holds every kind of edge
+// (literal, "+", and "#") in the same map, since they're just distinct
+// map keys; wildcardSubscribers holds every subscriber whose
+// SubscribePattern call registered a pattern terminating exactly at this
+// node, keyed by client ID.
+type trieNode struct {
+	children            map[string]*trieNode
+	wildcardSubscribers map[string]*subscriber.Subscriber
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{
+		children:            make(map[string]*trieNode),
+		wildcardSubscribers: make(map[string]*subscriber.Subscriber),
+	}
+}
+
+// TopicTrie indexes MQTT-style hierarchical subscription patterns ("+"
+// matches exactly one segment, "#" matches the rest of the path) by "/"
+// separated segment, so Registry.PublishMessage can find every pattern
+// matching a published topic name in O(depth × fanout_of_wildcards)
+// instead of testing every registered pattern against it. It only tracks
+// pattern subscriptions; registry.topics remains the source of truth for
+// which concrete topics exist.
+type TopicTrie struct {
+	mu   sync.RWMutex
+	root *trieNode
+}
+
+// newTopicTrie creates an empty TopicTrie.
+func newTopicTrie() *TopicTrie {
+	return &TopicTrie{root: newTrieNode()}
+}
+
+// splitPattern splits a topic name or subscription pattern on "/",
+// rejecting it with ErrInvalidTopicName if any segment is empty or if "#"
+// appears anywhere but the final segment ("#" matches the rest of the
+// path, so one later in the pattern would be unreachable).
+func splitPattern(name string) ([]string, error) {
+	if name == "" {
+		return nil, ErrInvalidTopicName
+	}
+
+	segments := strings.Split(name, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			return nil, ErrInvalidTopicName
+		}
+		if seg == "#" && i != len(segments)-1 {
+			return nil, ErrInvalidTopicName
+		}
+	}
+	return segments, nil
+}
+
+// Subscribe registers sub under pattern, creating trie nodes for any
+// literal, "+", or "#" segment not already present. Returns
+// ErrInvalidTopicName if pattern is malformed (see splitPattern).
+func (tr *TopicTrie) Subscribe(pattern string, sub *subscriber.Subscriber) error {
+	segments, err := splitPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	node := tr.root
+	for _, seg := range segments {
+		child, ok := node.children[seg]
+		if !ok {
+			child = newTrieNode()
+			node.children[seg] = child
+		}
+		node = child
+	}
+	node.wildcardSubscribers[sub.GetClientID()] = sub
+	return nil
+}
+
+// Unsubscribe removes clientID's registration under pattern, if any,
+// pruning it and every now-empty ancestor node along the way so a
+// pattern nobody subscribes to anymore doesn't linger in the trie. The
+// root itself is never pruned.
+func (tr *TopicTrie) Unsubscribe(pattern, clientID string) {
+	segments, err := splitPattern(pattern)
+	if err != nil {
+		return
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	unsubscribe(tr.root, segments, clientID)
+}
+
+// unsubscribe removes clientID from the node reached by segments, if any,
+// then prunes any child edge left with no subscribers and no children of
+// its own. Returns whether node itself is still referenced (has
+// subscribers or children) after the removal, so a caller one level up
+// knows whether to prune its edge to node.
+func unsubscribe(node *trieNode, segments []string, clientID string) bool {
+	if len(segments) == 0 {
+		delete(node.wildcardSubscribers, clientID)
+	} else {
+		seg, rest := segments[0], segments[1:]
+		if child, ok := node.children[seg]; ok {
+			if !unsubscribe(child, rest, clientID) {
+				delete(node.children, seg)
+			}
+		}
+	}
+	return len(node.wildcardSubscribers) > 0 || len(node.children) > 0
+}
+
+// Match returns every subscriber registered under a pattern matching
+// topicName, deduplicated by client ID (a subscriber registered under
+// more than one matching pattern, e.g. both "a/+/c" and "a/#", is
+// returned only once). It takes the trie's read lock for the duration of
+// the walk but never blocks on subscriber delivery, which callers do
+// after Match returns.
+func (tr *TopicTrie) Match(topicName string) []*subscriber.Subscriber {
+	segments, err := splitPattern(topicName)
+	if err != nil {
+		return nil
+	}
+
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	matched := make(map[string]*subscriber.Subscriber)
+	matchNode(tr.root, segments, matched)
+
+	result := make([]*subscriber.Subscriber, 0, len(matched))
+	for _, sub := range matched {
+		result = append(result, sub)
+	}
+	return result
+}
+
+// matchNode walks node against the remaining path segments, collecting
+// every subscriber reached via a "#" child (which matches the rest of
+// the path, including zero remaining segments) and, once segments is
+// exhausted, every subscriber registered exactly at node. It recurses at
+// most into a literal-matching child and a "+" child per level, so total
+// work is bounded by depth times the wildcard fanout at each level.
+func matchNode(node *trieNode, segments []string, matched map[string]*subscriber.Subscriber) {
+	if hashChild, ok := node.children["#"]; ok {
+		for id, sub := range hashChild.wildcardSubscribers {
+			matched[id] = sub
+		}
+	}
+
+	if len(segments) == 0 {
+		for id, sub := range node.wildcardSubscribers {
+			matched[id] = sub
+		}
+		return
+	}
+
+	seg, rest := segments[0], segments[1:]
+	if child, ok := node.children[seg]; ok {
+		matchNode(child, rest, matched)
+	}
+	if child, ok := node.children["+"]; ok {
+		matchNode(child, rest, matched)
+	}
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+)
+
+func TestTopicTrie_MatchLiteral(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	if err := trie.Subscribe("sensors/room1/temperature", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	matched := trie.Match("sensors/room1/temperature")
+	if len(matched) != 1 || matched[0].GetClientID() != "client-1" {
+		t.Fatalf("expected exactly client-1 to match, got %v", matched)
+	}
+
+	if matched := trie.Match("sensors/room1/humidity"); len(matched) != 0 {
+		t.Errorf("expected no match for a different topic, got %v", matched)
+	}
+}
+
+func TestTopicTrie_MatchSingleLevelWildcard(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	if err := trie.Subscribe("sensors/+/temperature", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if matched := trie.Match("sensors/room1/temperature"); len(matched) != 1 {
+		t.Errorf("expected + to match a single segment, got %v", matched)
+	}
+	if matched := trie.Match("sensors/room1/room2/temperature"); len(matched) != 0 {
+		t.Errorf("expected + not to match more than one segment, got %v", matched)
+	}
+}
+
+func TestTopicTrie_MatchMultiLevelWildcard(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	if err := trie.Subscribe("sensors/#", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if matched := trie.Match("sensors/room1/temperature"); len(matched) != 1 {
+		t.Errorf("expected # to match any remaining depth, got %v", matched)
+	}
+	if matched := trie.Match("sensors"); len(matched) != 1 {
+		t.Errorf("expected # to also match its own parent segment (MQTT semantics), got %v", matched)
+	}
+	if matched := trie.Match("other/room1"); len(matched) != 0 {
+		t.Errorf("expected # not to match an unrelated prefix, got %v", matched)
+	}
+}
+
+func TestTopicTrie_MatchDeduplicatesAcrossOverlappingPatterns(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	if err := trie.Subscribe("sensors/+/temperature", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := trie.Subscribe("sensors/#", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	matched := trie.Match("sensors/room1/temperature")
+	if len(matched) != 1 {
+		t.Errorf("expected client-1 to appear once despite matching two patterns, got %d matches", len(matched))
+	}
+}
+
+func TestTopicTrie_InvalidPattern(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	cases := []string{"", "a//b", "a/#/b"}
+	for _, pattern := range cases {
+		if err := trie.Subscribe(pattern, sub); err != ErrInvalidTopicName {
+			t.Errorf("pattern %q: expected ErrInvalidTopicName, got %v", pattern, err)
+		}
+	}
+}
+
+func TestTopicTrie_UnsubscribePrunesEmptyNodes(t *testing.T) {
+	trie := newTopicTrie()
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+
+	if err := trie.Subscribe("sensors/room1/temperature", sub); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	trie.Unsubscribe("sensors/room1/temperature", "client-1")
+
+	if matched := trie.Match("sensors/room1/temperature"); len(matched) != 0 {
+		t.Errorf("expected no match after Unsubscribe, got %v", matched)
+	}
+	if len(trie.root.children) != 0 {
+		t.Errorf("expected Unsubscribe to prune the now-empty branch, root still has %d children", len(trie.root.children))
+	}
+}
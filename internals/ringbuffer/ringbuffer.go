@@ -2,7 +2,10 @@
 package ringbuffer
 
 import (
+	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 )
@@ -10,11 +13,13 @@ import (
 // RingBuffer implements a thread-safe circular buffer for storing messages.
 // It maintains a fixed capacity and overwrites oldest messages when full.
 type RingBuffer struct {
-	buf  []models.Message
-	cap  int
-	head int
-	size int
-	mu   sync.RWMutex
+	buf     []models.Message
+	cap     int
+	head    int
+	size    int
+	nextSeq uint64
+	wal     *WAL
+	mu      sync.RWMutex
 }
 
 // NewRingBuffer creates a new ring buffer with the specified capacity.
@@ -30,12 +35,67 @@ func NewRingBuffer(capacity int) *RingBuffer {
 	}
 }
 
+// NewRingBufferWithWAL creates a ring buffer backed by a segmented on-disk
+// WAL rooted at path, using the default fsync policy (FsyncNever, i.e. rely
+// on the OS or an explicit Sync call). On open it replays the last
+// `capacity` records from the log back into the in-memory ring and resumes
+// sequence assignment from the highest sequence number it finds, so
+// per-topic sequence numbers survive a restart.
+func NewRingBufferWithWAL(capacity int, path string) (*RingBuffer, error) {
+	return NewRingBufferWithWALConfig(capacity, WALConfig{Dir: path})
+}
+
+// NewRingBufferWithWALConfig is NewRingBufferWithWAL with full control over
+// segment size, fsync policy, and retention. See WALConfig for field
+// semantics.
+func NewRingBufferWithWALConfig(capacity int, cfg WALConfig) (*RingBuffer, error) {
+	rb := NewRingBuffer(capacity)
+
+	wal, err := openWAL(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	records, maxSeq, err := wal.replay(rb.cap)
+	if err != nil {
+		wal.Close()
+		return nil, err
+	}
+
+	for _, m := range records {
+		rb.buf[rb.head] = m
+		rb.head = (rb.head + 1) % rb.cap
+		if rb.size < rb.cap {
+			rb.size++
+		}
+	}
+
+	rb.nextSeq = maxSeq
+	rb.wal = wal
+	return rb, nil
+}
+
 // Push adds a new message to the ring buffer.
 // If the buffer is full, it overwrites the oldest message.
+// Push assigns the message its monotonically increasing per-topic Seq (and
+// Created timestamp, if unset) and, when the ring buffer is WAL-backed,
+// persists it before storing it in memory.
 func (r *RingBuffer) Push(m models.Message) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.nextSeq++
+	m.Seq = r.nextSeq
+	if m.Created.IsZero() {
+		m.Created = time.Now()
+	}
+
+	if r.wal != nil {
+		if err := r.wal.append(m); err != nil {
+			log.Printf("ringbuffer: wal append failed: %v", err)
+		}
+	}
+
 	// Add message at current head position
 	r.buf[r.head] = m
 
@@ -84,6 +144,109 @@ func (r *RingBuffer) LastN(n int) []models.Message {
 	return result
 }
 
+// Since returns every retained message with Seq >= seq, in chronological
+// order (oldest to newest). If seq predates the oldest retained message,
+// the result simply starts at the oldest retained message; callers that
+// need to detect a gap should check OldestSeq.
+func (r *RingBuffer) Since(seq uint64) []models.Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]models.Message, 0, r.size)
+	start := (r.head - r.size + r.cap) % r.cap
+	for i := 0; i < r.size; i++ {
+		pos := (start + i) % r.cap
+		if r.buf[pos].Seq >= seq {
+			result = append(result, r.buf[pos])
+		}
+	}
+	return result
+}
+
+// OldestSeq returns the sequence number of the oldest message currently
+// retained in the buffer, and false if the buffer holds no messages.
+func (r *RingBuffer) OldestSeq() (uint64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.size == 0 {
+		return 0, false
+	}
+	start := (r.head - r.size + r.cap) % r.cap
+	return r.buf[start].Seq, true
+}
+
+// NextSeq returns the highest sequence number assigned so far (0 if none);
+// the next published message is assigned NextSeq()+1.
+func (r *RingBuffer) NextSeq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nextSeq
+}
+
+// FirstSeq returns the sequence number of the oldest message still durably
+// retained: on disk if this buffer is WAL-backed, or in the ring itself
+// otherwise. It returns false if nothing is retained at all.
+func (r *RingBuffer) FirstSeq() (uint64, bool) {
+	r.mu.RLock()
+	wal := r.wal
+	r.mu.RUnlock()
+
+	if wal == nil {
+		return r.OldestSeq()
+	}
+
+	seq, ok, err := wal.firstSeq()
+	if err != nil {
+		log.Printf("ringbuffer: wal firstSeq failed, falling back to ring: %v", err)
+		return r.OldestSeq()
+	}
+	return seq, ok
+}
+
+// ReplayFrom returns every retained message with Seq >= seq, oldest first,
+// reaching past the in-memory ring into the WAL on disk (when this buffer
+// is WAL-backed) for any portion of the range the ring has already evicted.
+func (r *RingBuffer) ReplayFrom(seq uint64) ([]models.Message, error) {
+	r.mu.RLock()
+	wal := r.wal
+	r.mu.RUnlock()
+
+	oldest, haveRing := r.OldestSeq()
+	if wal == nil || !haveRing || seq >= oldest {
+		return r.Since(seq), nil
+	}
+
+	onDisk, err := wal.sinceSeq(seq)
+	if err != nil {
+		return nil, fmt.Errorf("ringbuffer: replay from wal: %w", err)
+	}
+
+	result := make([]models.Message, 0, len(onDisk)+r.size)
+	for _, m := range onDisk {
+		if m.Seq < oldest {
+			result = append(result, m)
+		}
+	}
+	result = append(result, r.Since(seq)...)
+	return result, nil
+}
+
+// TruncateBefore compacts the on-disk WAL backing this ring buffer,
+// deleting whole log segments that hold nothing at or after seq. It never
+// touches the in-memory ring (already bounded by capacity) and is a no-op
+// for a ring buffer without a WAL.
+func (r *RingBuffer) TruncateBefore(seq uint64) error {
+	r.mu.RLock()
+	wal := r.wal
+	r.mu.RUnlock()
+
+	if wal == nil {
+		return nil
+	}
+	return wal.truncateBeforeSeq(seq)
+}
+
 // Size returns the current number of messages in the buffer.
 func (r *RingBuffer) Size() int {
 	r.mu.RLock()
@@ -109,3 +272,25 @@ func (r *RingBuffer) IsFull() bool {
 	defer r.mu.RUnlock()
 	return r.size == r.cap
 }
+
+// Sync flushes the underlying WAL to stable storage. It is a no-op for ring
+// buffers created without a WAL.
+func (r *RingBuffer) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.wal == nil {
+		return nil
+	}
+	return r.wal.Sync()
+}
+
+// Close stops the WAL's background segment rotation and closes its current
+// segment. It is a no-op for ring buffers created without a WAL.
+func (r *RingBuffer) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.wal == nil {
+		return nil
+	}
+	return r.wal.Close()
+}
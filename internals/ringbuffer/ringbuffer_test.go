@@ -107,6 +107,37 @@ func TestRingBuffer_LastN(t *testing.T) {
 	}
 }
 
+func TestRingBuffer_Since(t *testing.T) {
+	rb := NewRingBuffer(5)
+
+	for i := 1; i <= 5; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{}`)})
+	}
+
+	all := rb.LastN(5)
+	if len(all) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(all))
+	}
+	midSeq := all[2].Seq
+
+	result := rb.Since(midSeq)
+	if len(result) != 3 {
+		t.Fatalf("expected 3 messages since seq %d, got %d", midSeq, len(result))
+	}
+	if result[0].Seq != midSeq {
+		t.Errorf("expected first result seq %d, got %d", midSeq, result[0].Seq)
+	}
+
+	oldest, ok := rb.OldestSeq()
+	if !ok || oldest != all[0].Seq {
+		t.Errorf("expected OldestSeq %d, got %d (ok=%v)", all[0].Seq, oldest, ok)
+	}
+
+	if got := rb.Since(all[4].Seq + 1); len(got) != 0 {
+		t.Errorf("expected no messages beyond the newest seq, got %d", len(got))
+	}
+}
+
 func TestRingBuffer_ThreadSafety(t *testing.T) {
 	rb := NewRingBuffer(1000)
 	var wg sync.WaitGroup
@@ -0,0 +1,598 @@
+package ringbuffer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+const (
+	// defaultSegmentBytes is the segment size used when a WAL is opened
+	// without an explicit rotation threshold.
+	defaultSegmentBytes = 64 * 1024 * 1024
+
+	// segmentRotateCheckInterval controls how often the background
+	// rotation goroutine checks the current segment's size.
+	segmentRotateCheckInterval = 5 * time.Second
+)
+
+// FsyncPolicy controls how aggressively a WAL flushes appended records to
+// stable storage.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways fsyncs the current segment after every append. Safest,
+	// slowest.
+	FsyncAlways FsyncPolicy = "always"
+
+	// FsyncInterval fsyncs the current segment on a fixed background
+	// interval, bounding how much data a crash can lose.
+	FsyncInterval FsyncPolicy = "interval"
+
+	// FsyncNever relies on the OS to flush dirty pages on its own schedule
+	// (or an explicit Sync call). Fastest, least durable.
+	FsyncNever FsyncPolicy = "never"
+)
+
+// WALConfig configures a topic's on-disk write-ahead log.
+type WALConfig struct {
+	// Dir is the directory the WAL's segment files live in.
+	Dir string
+
+	// SegmentBytes is the rotation threshold. Zero means defaultSegmentBytes.
+	SegmentBytes int64
+
+	// FsyncPolicy selects when appended records are flushed to stable
+	// storage. Empty means FsyncNever, matching historical behavior.
+	FsyncPolicy FsyncPolicy
+
+	// FsyncInterval is the flush period when FsyncPolicy is FsyncInterval.
+	// Zero falls back to segmentRotateCheckInterval.
+	FsyncInterval time.Duration
+
+	// Retention, if positive, prunes whole segments on open whose newest
+	// record is older than Retention. The current (most recently written)
+	// segment is never pruned.
+	Retention time.Duration
+
+	// MaxTotalBytes, if positive, bounds the total on-disk size of all
+	// segments: the oldest segments (never the current one) are deleted
+	// until the total falls back under this limit. Enforced on open and
+	// periodically in the background alongside age-based retention.
+	MaxTotalBytes int64
+}
+
+// WAL is a segmented, append-only write-ahead log for a single topic's ring
+// buffer. Each record is a length-prefixed JSON-encoded models.Message, and
+// segments are rotated once they grow past segmentBytes so old history can
+// eventually be archived or pruned independently.
+type WAL struct {
+	dir           string
+	segmentBytes  int64
+	fsyncPolicy   FsyncPolicy
+	fsyncInterval time.Duration
+	retention     time.Duration
+	maxTotalBytes int64
+
+	mu       sync.Mutex
+	cur      *os.File
+	curSize  int64
+	curIndex int
+
+	idxMu       sync.Mutex
+	segFirstSeq map[int]uint64 // lazily populated from each segment's first record
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// openWAL opens (creating if necessary) the WAL directory for a topic,
+// prunes segments older than cfg.Retention, and resumes appending to the
+// most recent segment.
+func openWAL(cfg WALConfig) (*WAL, error) {
+	segmentBytes := cfg.SegmentBytes
+	if segmentBytes <= 0 {
+		segmentBytes = defaultSegmentBytes
+	}
+	fsyncInterval := cfg.FsyncInterval
+	if fsyncInterval <= 0 {
+		fsyncInterval = segmentRotateCheckInterval
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ringbuffer: create wal dir: %w", err)
+	}
+
+	w := &WAL{
+		dir:           cfg.Dir,
+		segmentBytes:  segmentBytes,
+		fsyncPolicy:   cfg.FsyncPolicy,
+		fsyncInterval: fsyncInterval,
+		retention:     cfg.Retention,
+		maxTotalBytes: cfg.MaxTotalBytes,
+		segFirstSeq:   make(map[int]uint64),
+		done:          make(chan struct{}),
+	}
+
+	if err := w.enforceRetention(); err != nil {
+		return nil, err
+	}
+
+	if err := w.openLatestSegment(); err != nil {
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.rotateLoop()
+
+	return w, nil
+}
+
+func (w *WAL) segmentPath(index int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("%08d.log", index))
+}
+
+func (w *WAL) listSegments() ([]int, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var indices []int
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".log") {
+			continue
+		}
+		idx, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".log"))
+		if err != nil {
+			continue
+		}
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
+func (w *WAL) openLatestSegment() error {
+	indices, err := w.listSegments()
+	if err != nil {
+		return err
+	}
+
+	index := 0
+	if len(indices) > 0 {
+		index = indices[len(indices)-1]
+	}
+
+	f, err := os.OpenFile(w.segmentPath(index), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("ringbuffer: open wal segment: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.cur = f
+	w.curIndex = index
+	w.curSize = info.Size()
+	return nil
+}
+
+// append writes m to the current segment, rotating to a new segment first
+// if the write would push it past segmentBytes.
+func (w *WAL) append(m models.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("ringbuffer: marshal wal record: %w", err)
+	}
+
+	if w.curSize > 0 && w.curSize+int64(len(payload))+4 > w.segmentBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+	if _, err := w.cur.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	if _, err := w.cur.Write(payload); err != nil {
+		return err
+	}
+	w.curSize += int64(len(payload)) + 4
+
+	if w.fsyncPolicy == FsyncAlways {
+		return w.cur.Sync()
+	}
+	return nil
+}
+
+// rotate closes the current segment and opens the next one. Callers must
+// hold w.mu.
+func (w *WAL) rotate() error {
+	if err := w.cur.Close(); err != nil {
+		return err
+	}
+	w.curIndex++
+	f, err := os.OpenFile(w.segmentPath(w.curIndex), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w.cur = f
+	w.curSize = 0
+	return nil
+}
+
+// rotateLoop periodically rotates the current segment in the background so
+// a long-lived segment that crept past the threshold between appends still
+// gets cut over promptly.
+func (w *WAL) rotateLoop() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(segmentRotateCheckInterval)
+	defer ticker.Stop()
+
+	var fsyncC <-chan time.Time
+	if w.fsyncPolicy == FsyncInterval {
+		fsyncTicker := time.NewTicker(w.fsyncInterval)
+		defer fsyncTicker.Stop()
+		fsyncC = fsyncTicker.C
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.curSize >= w.segmentBytes {
+				if err := w.rotate(); err != nil {
+					log.Printf("ringbuffer: background segment rotation failed: %v", err)
+				}
+			}
+			w.mu.Unlock()
+
+			if err := w.enforceRetention(); err != nil {
+				log.Printf("ringbuffer: background retention prune failed: %v", err)
+			}
+		case <-fsyncC:
+			w.mu.Lock()
+			if err := w.cur.Sync(); err != nil {
+				log.Printf("ringbuffer: interval fsync failed: %v", err)
+			}
+			w.mu.Unlock()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// replay reads every segment in order and returns the most recent `limit`
+// records (0 means unlimited) along with the highest sequence number seen,
+// so the caller can resume sequence assignment after a restart.
+func (w *WAL) replay(limit int) ([]models.Message, uint64, error) {
+	indices, err := w.listSegments()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var all []models.Message
+	var maxSeq uint64
+	for _, idx := range indices {
+		f, err := os.Open(w.segmentPath(idx))
+		if err != nil {
+			return nil, 0, err
+		}
+		records, err := readSegment(f)
+		f.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, m := range records {
+			if m.Seq > maxSeq {
+				maxSeq = m.Seq
+			}
+			all = append(all, m)
+			if limit > 0 && len(all) > limit {
+				all = all[len(all)-limit:]
+			}
+		}
+	}
+
+	return all, maxSeq, nil
+}
+
+// segmentFirstSeq returns the Seq of the first record in segment idx,
+// consulting (and populating) the lazily-built in-memory index so repeated
+// lookups don't re-read the file.
+func (w *WAL) segmentFirstSeq(idx int) (uint64, bool, error) {
+	w.idxMu.Lock()
+	if seq, ok := w.segFirstSeq[idx]; ok {
+		w.idxMu.Unlock()
+		return seq, true, nil
+	}
+	w.idxMu.Unlock()
+
+	f, err := os.Open(w.segmentPath(idx))
+	if err != nil {
+		return 0, false, err
+	}
+	records, err := readSegment(f)
+	f.Close()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(records) == 0 {
+		return 0, false, nil
+	}
+
+	seq := records[0].Seq
+	w.idxMu.Lock()
+	w.segFirstSeq[idx] = seq
+	w.idxMu.Unlock()
+	return seq, true, nil
+}
+
+// sinceSeq reads every segment and returns the records with Seq >= seq, in
+// order, skipping whole segments the index proves are entirely below seq.
+func (w *WAL) sinceSeq(seq uint64) ([]models.Message, error) {
+	indices, err := w.listSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the last segment whose first record is <= seq; every earlier
+	// segment is entirely below seq and can be skipped outright.
+	start := 0
+	for i, idx := range indices {
+		first, ok, err := w.segmentFirstSeq(idx)
+		if err != nil {
+			return nil, err
+		}
+		if ok && first <= seq {
+			start = i
+		}
+	}
+
+	var result []models.Message
+	for _, idx := range indices[start:] {
+		f, err := os.Open(w.segmentPath(idx))
+		if err != nil {
+			return nil, err
+		}
+		records, err := readSegment(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range records {
+			if m.Seq >= seq {
+				result = append(result, m)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// firstSeq returns the Seq of the oldest record still retained on disk, and
+// false if the WAL holds no records.
+func (w *WAL) firstSeq() (uint64, bool, error) {
+	indices, err := w.listSegments()
+	if err != nil {
+		return 0, false, err
+	}
+	for _, idx := range indices {
+		seq, ok, err := w.segmentFirstSeq(idx)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			return seq, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// enforceRetention is the WAL's background compactor: it prunes segments by
+// age (w.retention) and by total on-disk size (w.maxTotalBytes), never
+// touching the current segment. Either limit being zero disables that
+// dimension. Safe to call repeatedly; a no-op once both limits are satisfied.
+func (w *WAL) enforceRetention() error {
+	if w.retention > 0 {
+		if err := w.truncateOlderThan(time.Now().Add(-w.retention)); err != nil {
+			return err
+		}
+	}
+	if w.maxTotalBytes > 0 {
+		if err := w.truncateOverSize(w.maxTotalBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateOverSize deletes the oldest segments, except the current one,
+// until the total size of all remaining segments is at or under maxBytes.
+func (w *WAL) truncateOverSize(maxBytes int64) error {
+	indices, err := w.listSegments()
+	if err != nil {
+		return err
+	}
+	if len(indices) <= 1 {
+		return nil // nothing to prune, or only the active segment exists
+	}
+
+	sizes := make([]int64, len(indices))
+	var total int64
+	for i, idx := range indices {
+		info, err := os.Stat(w.segmentPath(idx))
+		if err != nil {
+			return err
+		}
+		sizes[i] = info.Size()
+		total += info.Size()
+	}
+
+	for i := 0; i < len(indices)-1 && total > maxBytes; i++ {
+		if err := os.Remove(w.segmentPath(indices[i])); err != nil {
+			return err
+		}
+		total -= sizes[i]
+	}
+
+	return nil
+}
+
+// truncateOlderThan deletes every segment, except the most recent one,
+// whose last record was created before cutoff. Callers must hold no lock;
+// it runs from enforceRetention on the background rotation goroutine.
+func (w *WAL) truncateOlderThan(cutoff time.Time) error {
+	indices, err := w.listSegments()
+	if err != nil {
+		return err
+	}
+	if len(indices) <= 1 {
+		return nil // nothing to prune, or only the active segment exists
+	}
+
+	for _, idx := range indices[:len(indices)-1] {
+		f, err := os.Open(w.segmentPath(idx))
+		if err != nil {
+			return err
+		}
+		records, err := readSegment(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			continue
+		}
+		newest := records[len(records)-1].Created
+		if newest.Before(cutoff) {
+			if err := os.Remove(w.segmentPath(idx)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// truncateBeforeSeq deletes every segment, except the most recent one,
+// whose last record's Seq is below seq, compacting the log down to the
+// messages a client could still legitimately want to replay from seq
+// onward. Unlike enforceRetention's prunes, this is driven by an explicit
+// compaction request (see Topic.TruncateBefore) rather than a background
+// policy.
+func (w *WAL) truncateBeforeSeq(seq uint64) error {
+	indices, err := w.listSegments()
+	if err != nil {
+		return err
+	}
+	if len(indices) <= 1 {
+		return nil // nothing to prune, or only the active segment exists
+	}
+
+	for _, idx := range indices[:len(indices)-1] {
+		f, err := os.Open(w.segmentPath(idx))
+		if err != nil {
+			return err
+		}
+		records, err := readSegment(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if records[len(records)-1].Seq < seq {
+			if err := os.Remove(w.segmentPath(idx)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// readSegment parses every length-prefixed record in f in order. A torn
+// trailing record — a length prefix or payload cut short, or a payload that
+// fails to unmarshal — is exactly what an unclean shutdown (crash, kill -9)
+// mid-append leaves behind: it is logged and treated as the end of the
+// segment rather than a hard error, so the records written and fsynced
+// before the crash are still recovered instead of being discarded along
+// with the torn one.
+func readSegment(f *os.File) ([]models.Message, error) {
+	r := bufio.NewReader(f)
+
+	var records []models.Message
+	for {
+		var lenBuf [4]byte
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if err == io.ErrUnexpectedEOF {
+				log.Printf("ringbuffer: %s: torn record length prefix at tail, stopping replay here: %v", f.Name(), err)
+				break
+			}
+			return nil, err
+		}
+
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				log.Printf("ringbuffer: %s: torn record payload at tail, stopping replay here: %v", f.Name(), err)
+				break
+			}
+			return nil, err
+		}
+
+		var m models.Message
+		if err := json.Unmarshal(payload, &m); err != nil {
+			log.Printf("ringbuffer: %s: corrupt trailing record, stopping replay here: %v", f.Name(), err)
+			break
+		}
+		records = append(records, m)
+	}
+
+	return records, nil
+}
+
+// Sync flushes the current segment to stable storage.
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cur.Sync()
+}
+
+// Close stops background rotation and closes the current segment. It does
+// not remove any files on disk; callers that want to delete the WAL's data
+// should os.RemoveAll the directory after Close returns.
+func (w *WAL) Close() error {
+	close(w.done)
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cur.Close()
+}
@@ -0,0 +1,280 @@
+package ringbuffer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+func TestRingBuffer_WAL_PersistsAndReplays(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+
+	rb, err := NewRingBufferWithWAL(3, dir)
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWAL failed: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+
+	if err := rb.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	rb2, err := NewRingBufferWithWAL(3, dir)
+	if err != nil {
+		t.Fatalf("reopening WAL failed: %v", err)
+	}
+	defer rb2.Close()
+
+	messages := rb2.LastN(10)
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 replayed messages, got %d", len(messages))
+	}
+	if messages[0].ID != "3" || messages[2].ID != "5" {
+		t.Errorf("unexpected replay order: %+v", messages)
+	}
+	for i, m := range messages {
+		if m.Seq == 0 {
+			t.Errorf("message %d missing Seq after replay", i)
+		}
+	}
+
+	// Sequence assignment must continue from the highest seen, not reset.
+	rb2.Push(models.Message{ID: "6", Payload: json.RawMessage(`{"n":1}`)})
+	last := rb2.LastN(1)
+	if len(last) != 1 || last[0].Seq != 6 {
+		t.Errorf("expected next seq to continue at 6, got %+v", last)
+	}
+}
+
+func TestRingBuffer_ReplayFrom_ReachesPastRing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+
+	rb, err := NewRingBufferWithWALConfig(2, WALConfig{Dir: dir, FsyncPolicy: FsyncAlways})
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWALConfig failed: %v", err)
+	}
+	defer rb.Close()
+
+	for i := 1; i <= 5; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+
+	// The ring only holds the last 2 (seq 4 and 5); seq 1-3 only exist on disk.
+	if _, ok := rb.OldestSeq(); !ok {
+		t.Fatalf("expected ring to retain messages")
+	}
+
+	first, ok := rb.FirstSeq()
+	if !ok || first != 1 {
+		t.Fatalf("expected FirstSeq 1, got %d, ok=%v", first, ok)
+	}
+
+	replayed, err := rb.ReplayFrom(2)
+	if err != nil {
+		t.Fatalf("ReplayFrom failed: %v", err)
+	}
+	if len(replayed) != 4 {
+		t.Fatalf("expected 4 replayed messages (seq 2-5), got %d: %+v", len(replayed), replayed)
+	}
+	for i, want := range []uint64{2, 3, 4, 5} {
+		if replayed[i].Seq != want {
+			t.Errorf("replayed[%d].Seq = %d, want %d", i, replayed[i].Seq, want)
+		}
+	}
+
+	if rb.NextSeq() != 5 {
+		t.Errorf("NextSeq() = %d, want 5", rb.NextSeq())
+	}
+}
+
+func TestWAL_TruncateOlderThan_KeepsCurrentSegment(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "events")
+
+	rb, err := NewRingBufferWithWALConfig(10, WALConfig{Dir: dir, SegmentBytes: 1})
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWALConfig failed: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Every segment was just written, so a long retention window prunes nothing.
+	rb2, err := NewRingBufferWithWALConfig(10, WALConfig{Dir: dir, SegmentBytes: 1, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("reopening with retention failed: %v", err)
+	}
+	defer rb2.Close()
+
+	messages := rb2.LastN(10)
+	if len(messages) != 3 {
+		t.Fatalf("expected retention to keep all 3 recent messages, got %d", len(messages))
+	}
+}
+
+func TestWAL_MaxTotalBytes_PrunesOldestSegments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "events")
+
+	rb, err := NewRingBufferWithWALConfig(10, WALConfig{Dir: dir, SegmentBytes: 1})
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWALConfig failed: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	segBefore, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(segBefore) != 5 {
+		t.Fatalf("expected 5 segments (one per message), got %d", len(segBefore))
+	}
+
+	// Budget room for only the single newest segment; every older one must
+	// be pruned on open, but the current segment is always kept.
+	rb2, err := NewRingBufferWithWALConfig(10, WALConfig{Dir: dir, SegmentBytes: 1, MaxTotalBytes: 1})
+	if err != nil {
+		t.Fatalf("reopening with MaxTotalBytes failed: %v", err)
+	}
+	defer rb2.Close()
+
+	segAfter, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(segAfter) != 1 {
+		t.Fatalf("expected pruning down to 1 segment, got %d", len(segAfter))
+	}
+
+	messages := rb2.LastN(10)
+	if len(messages) != 1 || messages[0].ID != "5" {
+		t.Fatalf("expected only the newest message to survive pruning, got %+v", messages)
+	}
+}
+
+func TestRingBuffer_TruncateBefore_DropsFullyConsumedSegments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "events")
+
+	rb, err := NewRingBufferWithWALConfig(2, WALConfig{Dir: dir, SegmentBytes: 1})
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWALConfig failed: %v", err)
+	}
+	defer rb.Close()
+
+	for i := 1; i <= 5; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+
+	segBefore, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(segBefore) != 5 {
+		t.Fatalf("expected 5 segments (one per message), got %d", len(segBefore))
+	}
+
+	// Messages are seq 1..5; truncating before 4 should drop segments 1-3
+	// but keep the active segment and anything from seq 4 onward.
+	if err := rb.TruncateBefore(4); err != nil {
+		t.Fatalf("TruncateBefore failed: %v", err)
+	}
+
+	segAfter, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(segAfter) != 2 {
+		t.Fatalf("expected 2 segments to survive truncation, got %d", len(segAfter))
+	}
+
+	messages, err := rb.ReplayFrom(1)
+	if err != nil {
+		t.Fatalf("ReplayFrom failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].ID != "4" || messages[1].ID != "5" {
+		t.Fatalf("expected only seq 4-5 to survive truncation, got %+v", messages)
+	}
+}
+
+func TestRingBuffer_TruncateBefore_NoWAL_IsNoop(t *testing.T) {
+	rb := NewRingBuffer(4)
+	defer rb.Close()
+
+	rb.Push(models.Message{ID: "1", Payload: json.RawMessage(`{"n":1}`)})
+
+	if err := rb.TruncateBefore(100); err != nil {
+		t.Fatalf("TruncateBefore on a WAL-less ring buffer should be a no-op, got error: %v", err)
+	}
+}
+
+// TestRingBuffer_WAL_ReplaySurvivesTornTrailingRecord simulates the segment
+// state an unclean shutdown (crash, kill -9) leaves behind: a good prefix of
+// fully-written records followed by a length-prefixed record cut short
+// mid-append. Replay must recover the good prefix instead of failing the
+// whole segment.
+func TestRingBuffer_WAL_ReplaySurvivesTornTrailingRecord(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+
+	rb, err := NewRingBufferWithWAL(10, dir)
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWAL failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		rb.Push(models.Message{ID: string(rune('0' + i)), Payload: json.RawMessage(`{"n":1}`)})
+	}
+	if err := rb.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if err := rb.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected exactly one segment, got %v (err %v)", entries, err)
+	}
+	segPath := filepath.Join(dir, entries[0].Name())
+
+	f, err := os.OpenFile(segPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("opening segment for corruption failed: %v", err)
+	}
+	// A length prefix claiming a large payload, with none of that payload
+	// actually written: exactly what a process kill mid-append leaves.
+	if _, err := f.Write([]byte{0x00, 0x00, 0x10, 0x00}); err != nil {
+		t.Fatalf("writing torn record failed: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("closing corrupted segment failed: %v", err)
+	}
+
+	rb2, err := NewRingBufferWithWAL(10, dir)
+	if err != nil {
+		t.Fatalf("reopening WAL with a torn trailing record should not fail, got: %v", err)
+	}
+	defer rb2.Close()
+
+	messages := rb2.LastN(10)
+	if len(messages) != 2 {
+		t.Fatalf("expected the 2 valid records to survive the torn tail, got %d: %+v", len(messages), messages)
+	}
+	if messages[0].ID != "1" || messages[1].ID != "2" {
+		t.Errorf("unexpected replay contents: %+v", messages)
+	}
+}
@@ -3,54 +3,374 @@ package subscriber
 
 import (
 	"context"
+	"encoding/json"
 	"log"
+	"net"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/codec"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/filter"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 )
 
-// Subscriber represents a WebSocket client connection with message handling capabilities.
+// Sink abstracts the transport a Subscriber writes its messages to, so the
+// same Subscriber/StartWriter machinery can drive a WebSocket connection or
+// any other stream (e.g. an SSE response writer) interchangeably.
+type Sink interface {
+	// WriteMessage writes a single message to the sink. writeTimeout, if
+	// non-zero, bounds how long the write may take; sinks without a native
+	// deadline concept may ignore it.
+	WriteMessage(msg models.ServerMsg, writeTimeout time.Duration) error
+
+	// Close closes the underlying connection.
+	Close() error
+}
+
+// wsSink adapts a *websocket.Conn to the Sink interface. codec is the wire
+// format negotiated for this connection (see Subscriber.SetCodec); it
+// defaults to codec.JSON, matching the original WriteJSON behavior.
+type wsSink struct {
+	conn  *websocket.Conn
+	codec codec.Codec
+}
+
+func (s *wsSink) WriteMessage(msg models.ServerMsg, writeTimeout time.Duration) error {
+	if writeTimeout > 0 {
+		if err := s.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+			return err
+		}
+	}
+
+	c := s.codec
+	if c == nil {
+		c = codec.JSON
+	}
+	payload, contentType, err := c.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if contentType == byte(codec.ContentTypeJSON) {
+		return s.conn.WriteMessage(websocket.TextMessage, payload)
+	}
+	return s.conn.WriteMessage(websocket.BinaryMessage, append([]byte{contentType}, payload...))
+}
+
+func (s *wsSink) Close() error {
+	return s.conn.Close()
+}
+
+// InFlight tracks a message delivered to a subscriber under an
+// acknowledgement-based OverflowPolicy (see topic.AckModePolicy) until it is
+// acknowledged, redelivered, or dead-lettered.
+type InFlight struct {
+	// Topic is the name of the topic the message was published to, so it
+	// can be redelivered or dead-lettered against the right topic.
+	Topic string
+
+	Msg models.Message
+
+	// Attempts counts delivery attempts so far, including the initial one.
+	Attempts int
+
+	// Deadline is when this delivery is next eligible for redelivery if not
+	// acknowledged before then.
+	Deadline time.Time
+}
+
+// RetryStrategy selects how RetryPolicy.NextBackoff grows the delay
+// between redelivery attempts.
+type RetryStrategy string
+
+const (
+	// RetryFixed redelivers at a constant Backoff interval.
+	RetryFixed RetryStrategy = "fixed"
+	// RetryExponential doubles Backoff after every attempt.
+	RetryExponential RetryStrategy = "exponential"
+)
+
+// RetryPolicy configures how a message this subscriber failed to accept
+// (e.g. its buffer overflowed) is redelivered before being dead-lettered.
+// See SetRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is how many redelivery attempts are made after the
+	// original drop before giving up. Zero (the default) disables retry:
+	// a drop goes straight to the DLQ.
+	MaxAttempts int
+
+	// Backoff is the delay before the first redelivery attempt.
+	Backoff time.Duration
+
+	// Strategy grows Backoff across attempts. Empty defaults to RetryFixed.
+	Strategy RetryStrategy
+}
+
+// NextBackoff returns the delay to wait before redelivery attempt number
+// attempt (1-indexed: the first retry after the original drop is attempt
+// 1). RetryExponential doubles p.Backoff for every attempt beyond the
+// first; any other Strategy repeats p.Backoff unchanged.
+func (p RetryPolicy) NextBackoff(attempt int) time.Duration {
+	if p.Strategy != RetryExponential || attempt <= 1 {
+		return p.Backoff
+	}
+	return p.Backoff * time.Duration(uint64(1)<<uint(attempt-1))
+}
+
+// DefaultPongWait is how long a subscriber's connection may go without a
+// pong (or any other read) before StartWriter gives up on it.
+const DefaultPongWait = 60 * time.Second
+
+// DefaultPingPeriod is how often StartWriter sends a WebSocket ping frame
+// to a subscriber backed by a real connection, keeping idle connections
+// alive and surfacing dead peers well before DefaultPongWait would. It must
+// stay under DefaultPongWait, hence the 9/10 margin.
+const DefaultPingPeriod = DefaultPongWait * 9 / 10
+
+// DefaultWriteWait bounds how long a single ping write may take before
+// StartWriter treats it as a failed write and tears the subscriber down.
+const DefaultWriteWait = 10 * time.Second
+
+// MessageHandler processes an inbound client frame once StartReader has
+// decoded it, for message types a Subscriber doesn't already handle itself
+// (ack, nack, modack — see Ack/Nack/ModAck). A Registry satisfies this
+// interface to route "response" frames back to a pending Request call; see
+// SetMessageHandler and topic.Topic.SetMessageHandler.
+type MessageHandler interface {
+	HandleClientMessage(s *Subscriber, msg models.WSClientMsg)
+}
+
+// Subscriber represents a client connection with message handling capabilities.
 // It ensures thread-safe message delivery through a dedicated writer goroutine.
 type Subscriber struct {
 	ClientID  string
 	Conn      *websocket.Conn
 	Send      chan models.ServerMsg
 	Done      chan struct{}
+	sink      Sink
 	closeOnce sync.Once
+	doneOnce  sync.Once
+
+	inFlightMu sync.Mutex
+	inFlight   map[string]*InFlight // message ID -> in-flight record
+
+	// filter, if set, restricts delivery to messages matching it (see
+	// SetFilter and Match).
+	filter *filter.Predicate
+
+	// retryPolicy governs redelivery of a message dropped because this
+	// subscriber's buffer overflowed (see SetRetryPolicy). The zero value
+	// disables retry.
+	retryPolicy RetryPolicy
+
+	// codec is the wire format this subscriber's sink marshals outbound
+	// messages with (see SetCodec). nil means codec.JSON.
+	codec codec.Codec
+
+	// pingPeriod and pongWait configure the keepalive liveness check
+	// StartWriter runs against Conn (see SetKeepalive).
+	pingPeriod time.Duration
+	pongWait   time.Duration
+
+	lastPongMu sync.Mutex
+	lastPongAt time.Time
+
+	// metricsReg and topicName report keepalive timeouts to an operator's
+	// metrics backend (see SetMetricsRegistry). metricsReg defaults to
+	// metrics.Noop.
+	metricsReg metrics.Registry
+	topicName  string
+
+	// handler receives every inbound frame StartReader decodes that isn't
+	// an ack, nack, or modack (see SetMessageHandler). nil means inbound
+	// frames other than those three are simply ignored.
+	handler MessageHandler
 }
 
 // NewSubscriber creates a new subscriber with the specified client ID and WebSocket connection.
-// The buf parameter sets the buffer size for the Send channel.
+// The buf parameter sets the buffer size for the Send channel. conn may be
+// nil when the subscriber is only used to forward messages to some other
+// consumer (see transport.MemoryTransport), in which case the writer never
+// has anywhere to write and simply drains Send.
 func NewSubscriber(clientID string, conn *websocket.Conn, buf int) *Subscriber {
 	if buf <= 0 {
 		buf = 100 // Default buffer size
 	}
 
+	s := &Subscriber{
+		ClientID:   clientID,
+		Conn:       conn,
+		Send:       make(chan models.ServerMsg, buf),
+		Done:       make(chan struct{}),
+		pingPeriod: DefaultPingPeriod,
+		pongWait:   DefaultPongWait,
+		metricsReg: metrics.Noop(),
+	}
+	if conn != nil {
+		s.sink = &wsSink{conn: conn}
+	}
+	return s
+}
+
+// NewSubscriberWithSink creates a new subscriber backed by an arbitrary Sink
+// instead of a WebSocket connection, e.g. an SSE response writer.
+func NewSubscriberWithSink(clientID string, sink Sink, buf int) *Subscriber {
+	if buf <= 0 {
+		buf = 100 // Default buffer size
+	}
+
 	return &Subscriber{
-		ClientID: clientID,
-		Conn:     conn,
-		Send:     make(chan models.ServerMsg, buf),
-		Done:     make(chan struct{}),
+		ClientID:   clientID,
+		Send:       make(chan models.ServerMsg, buf),
+		Done:       make(chan struct{}),
+		sink:       sink,
+		pingPeriod: DefaultPingPeriod,
+		pongWait:   DefaultPongWait,
+		metricsReg: metrics.Noop(),
+	}
+}
+
+// SetMetricsRegistry wires reg into the subscriber so a ping or pong that
+// fails with a timeout is reported against topic, letting operators see
+// silent client disconnects instead of the subscriber just quietly going
+// inactive. A nil reg resets it to metrics.Noop.
+func (s *Subscriber) SetMetricsRegistry(reg metrics.Registry, topic string) {
+	if reg == nil {
+		reg = metrics.Noop()
+	}
+	s.metricsReg = reg
+	s.topicName = topic
+}
+
+// SetMessageHandler wires h into the subscriber so StartReader dispatches
+// inbound frames it doesn't handle itself (anything other than ack, nack,
+// or modack) to h.HandleClientMessage. A nil h disables dispatch; inbound
+// frames of those other types are then simply dropped.
+func (s *Subscriber) SetMessageHandler(h MessageHandler) {
+	s.handler = h
+}
+
+// SetKeepalive overrides the ping/pong liveness timeouts StartWriter uses
+// for a subscriber backed by a real WebSocket connection (Conn != nil).
+// Non-positive values leave the corresponding default unchanged. Must be
+// called before StartWriter to take effect.
+func (s *Subscriber) SetKeepalive(pingPeriod, pongWait time.Duration) {
+	if pingPeriod > 0 {
+		s.pingPeriod = pingPeriod
+	}
+	if pongWait > 0 {
+		s.pongWait = pongWait
+	}
+}
+
+// LastPongAt returns the time of the last pong (or other read) received on
+// Conn, or the zero Time if none has been recorded yet (e.g. StartWriter
+// hasn't run, or this subscriber has no Conn).
+func (s *Subscriber) LastPongAt() time.Time {
+	s.lastPongMu.Lock()
+	defer s.lastPongMu.Unlock()
+	return s.lastPongAt
+}
+
+func (s *Subscriber) recordPong() {
+	s.lastPongMu.Lock()
+	s.lastPongAt = time.Now()
+	s.lastPongMu.Unlock()
+}
+
+// closeDone closes Done exactly once, however it is first triggered: the
+// writer goroutine exiting, or readPump hitting a read error or missed
+// pong deadline.
+func (s *Subscriber) closeDone() {
+	s.doneOnce.Do(func() {
+		close(s.Done)
+	})
+}
+
+// StartReader consumes incoming frames on a subscriber's WebSocket
+// connection so the pong handler installed by StartWriter actually runs and
+// extends the read deadline. A decodable frame is dispatched: "ack",
+// "nack", and "modack" call Ack/Nack/ModAck directly, and anything else is
+// handed to the registered MessageHandler, if any (see SetMessageHandler).
+// A frame that fails to decode as JSON is ignored; a subscriber driven
+// through Send/StartWriter isn't required to accept inbound application
+// messages to stay alive (see subscriberService/http for the independent
+// dispatch loop production WebSocket connections use instead). It exits,
+// closing Done, on the first read error — including the deadline expiring
+// without a pong, which it reports through metricsReg as a timeout
+// disconnect. StartWriter starts this automatically for a subscriber backed
+// by a real Conn; it is exported so callers that only need the liveness
+// check, without a writer, can start it directly.
+func (s *Subscriber) StartReader() {
+	defer s.closeDone()
+	for {
+		_, data, err := s.Conn.ReadMessage()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				s.metricsReg.ObserveTimeoutDisconnect(s.topicName)
+			}
+			return
+		}
+
+		var msg models.WSClientMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "ack":
+			s.Ack(msg.ID)
+		case "nack":
+			s.Nack(msg.ID)
+		case "modack":
+			s.ModAck(msg.ID, time.Now().Add(msg.AckDeadline))
+		default:
+			if s.handler != nil {
+				s.handler.HandleClientMessage(s, msg)
+			}
+		}
 	}
 }
 
-// StartWriter launches a goroutine that continuously reads from the Send channel
-// and writes messages as JSON to the WebSocket connection.
+// StartWriter launches a goroutine that continuously reads from the Send
+// channel and writes messages to the subscriber's sink.
 //
 // CONCURRENCY NOTE: This is the ONLY goroutine that should write to the Conn.
 // All other code should send messages through the Send channel, never directly
 // to the WebSocket connection.
 //
+// When the subscriber has a real Conn (as opposed to a non-WebSocket Sink),
+// StartWriter also runs a keepalive: it sends a WebSocket ping every
+// pingPeriod, and a companion StartReader goroutine extends Conn's read
+// deadline by pongWait on every pong (installed via SetPongHandler). A
+// missed pong, any other read error, or a failed ping closes Done, letting
+// Topic.Publish's existing IsActive check prune the subscriber on the next
+// fan-out instead of leaving a half-open connection in Topic.subs. A
+// timeout on either side is reported through metricsReg (see
+// SetMetricsRegistry) so operators can see silent client disconnects.
+//
 // The writer will automatically close the Done channel on any write error,
 // signaling that the subscriber should be cleaned up.
 func (s *Subscriber) StartWriter(ctx context.Context, writeTimeout time.Duration) {
+	if s.Conn != nil {
+		s.Conn.SetReadDeadline(time.Now().Add(s.pongWait))
+		s.Conn.SetPongHandler(func(string) error {
+			s.recordPong()
+			return s.Conn.SetReadDeadline(time.Now().Add(s.pongWait))
+		})
+		go s.StartReader()
+	}
+
 	go func() {
-		defer func() {
-			// Signal completion by closing Done channel
-			close(s.Done)
-		}()
+		defer s.closeDone()
+
+		var pingTicker *time.Ticker
+		var pingC <-chan time.Time
+		if s.Conn != nil {
+			pingTicker = time.NewTicker(s.pingPeriod)
+			defer pingTicker.Stop()
+			pingC = pingTicker.C
+		}
 
 		for {
 			select {
@@ -65,19 +385,33 @@ func (s *Subscriber) StartWriter(ctx context.Context, writeTimeout time.Duration
 					return
 				}
 
-				// Set write deadline
-				if writeTimeout > 0 {
-					if err := s.Conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
-						log.Printf("Subscriber %s: failed to set write deadline: %v", s.ClientID, err)
-						return
-					}
+				if s.sink == nil {
+					// No sink attached (e.g. a transport-internal forwarder);
+					// nothing to write to.
+					continue
 				}
 
-				// Write message as JSON to WebSocket connection
-				if err := s.Conn.WriteJSON(msg); err != nil {
+				if err := s.sink.WriteMessage(msg, writeTimeout); err != nil {
 					log.Printf("Subscriber %s: failed to write message: %v", s.ClientID, err)
 					return
 				}
+
+			case <-pingC:
+				deadline := writeTimeout
+				if deadline <= 0 {
+					deadline = DefaultWriteWait
+				}
+				if err := s.Conn.SetWriteDeadline(time.Now().Add(deadline)); err != nil {
+					log.Printf("Subscriber %s: failed to set ping write deadline: %v", s.ClientID, err)
+					return
+				}
+				if err := s.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					log.Printf("Subscriber %s: ping failed: %v", s.ClientID, err)
+					if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+						s.metricsReg.ObserveTimeoutDisconnect(s.topicName)
+					}
+					return
+				}
 			}
 		}
 	}()
@@ -94,9 +428,9 @@ func (s *Subscriber) Close() {
 		// Wait for writer to complete
 		<-s.Done
 
-		// Close WebSocket connection
-		if s.Conn != nil {
-			s.Conn.Close()
+		// Close the underlying connection
+		if s.sink != nil {
+			s.sink.Close()
 		}
 
 		log.Printf("Subscriber %s: closed", s.ClientID)
@@ -130,3 +464,139 @@ func (s *Subscriber) IsActive() bool {
 func (s *Subscriber) GetClientID() string {
 	return s.ClientID
 }
+
+// SetFilter restricts this subscriber to messages matching pred. A nil
+// pred (the default) delivers every message.
+func (s *Subscriber) SetFilter(pred *filter.Predicate) {
+	s.filter = pred
+}
+
+// SetRetryPolicy configures how a message dropped because this
+// subscriber's buffer overflowed is redelivered before being dead-lettered
+// (see registry.Registry.SetDLQPolicy). The zero value disables retry.
+func (s *Subscriber) SetRetryPolicy(policy RetryPolicy) {
+	s.retryPolicy = policy
+}
+
+// RetryPolicy returns this subscriber's retry policy, previously set via
+// SetRetryPolicy, or the zero value (retry disabled) if none was set.
+func (s *Subscriber) RetryPolicy() RetryPolicy {
+	return s.retryPolicy
+}
+
+// SetCodec negotiates the wire format this subscriber's sink marshals
+// outbound messages with (see internals/codec). A nil c resets it to
+// codec.JSON, the default. Has no effect on a subscriber backed by a
+// non-WebSocket Sink (e.g. SSE), which always writes JSON.
+func (s *Subscriber) SetCodec(c codec.Codec) {
+	s.codec = c
+	if ws, ok := s.sink.(*wsSink); ok {
+		ws.codec = c
+	}
+}
+
+// Codec returns the wire format negotiated for this subscriber via
+// SetCodec, or nil if none was (meaning codec.JSON).
+func (s *Subscriber) Codec() codec.Codec {
+	return s.codec
+}
+
+// Match reports whether ctx passes this subscriber's filter. A subscriber
+// with no filter set matches everything. Callers evaluating one message
+// against many subscribers should build ctx once with filter.NewContext and
+// share it, rather than decoding the message's payload per subscriber.
+func (s *Subscriber) Match(ctx filter.Context) bool {
+	if s.filter == nil {
+		return true
+	}
+	return s.filter.MatchesContext(ctx)
+}
+
+// TrackInFlight records rec as delivered-but-unacknowledged, replacing any
+// existing in-flight record for the same message ID. Used by
+// acknowledgement-based OverflowPolicies.
+func (s *Subscriber) TrackInFlight(rec InFlight) {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]*InFlight)
+	}
+	r := rec
+	s.inFlight[rec.Msg.ID] = &r
+}
+
+// Ack acknowledges the in-flight message with the given ID, removing it
+// from tracking. Returns true if it was pending (i.e. the ack was
+// meaningful, not a duplicate or for an unknown ID).
+func (s *Subscriber) Ack(msgID string) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	if _, ok := s.inFlight[msgID]; !ok {
+		return false
+	}
+	delete(s.inFlight, msgID)
+	return true
+}
+
+// Nack forces immediate redelivery of the in-flight message with the given
+// ID by expiring its deadline, instead of waiting out the rest of its ack
+// timeout. Returns true if it was pending.
+func (s *Subscriber) Nack(msgID string) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	rec, ok := s.inFlight[msgID]
+	if !ok {
+		return false
+	}
+	rec.Deadline = time.Time{}
+	return true
+}
+
+// ModAck extends the ack deadline of the in-flight message with the given
+// ID to newDeadline, so a subscriber still processing it can avoid a
+// redelivery. Returns true if it was pending.
+func (s *Subscriber) ModAck(msgID string, newDeadline time.Time) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	rec, ok := s.inFlight[msgID]
+	if !ok {
+		return false
+	}
+	rec.Deadline = newDeadline
+	return true
+}
+
+// ExpiredInFlight removes and returns every in-flight record whose deadline
+// has passed as of now.
+func (s *Subscriber) ExpiredInFlight(now time.Time) []InFlight {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	var expired []InFlight
+	for id, rec := range s.inFlight {
+		if now.After(rec.Deadline) {
+			expired = append(expired, *rec)
+			delete(s.inFlight, id)
+		}
+	}
+	return expired
+}
+
+// DrainInFlight removes and returns every in-flight record regardless of
+// deadline, so a reconnecting subscriber can inherit them (see
+// Topic.AddSubscriber).
+func (s *Subscriber) DrainInFlight() []InFlight {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	drained := make([]InFlight, 0, len(s.inFlight))
+	for id, rec := range s.inFlight {
+		drained = append(drained, *rec)
+		delete(s.inFlight, id)
+	}
+	return drained
+}
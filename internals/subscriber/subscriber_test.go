@@ -9,9 +9,33 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/codec"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/compress"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 )
 
+// timeoutCountingRegistry wraps metrics.Noop, recording only calls to
+// ObserveTimeoutDisconnect so tests can assert on keepalive timeouts
+// without standing up a full PrometheusRegistry.
+type timeoutCountingRegistry struct {
+	metrics.Registry
+	mu    sync.Mutex
+	count int
+}
+
+func (r *timeoutCountingRegistry) ObserveTimeoutDisconnect(topic string) {
+	r.mu.Lock()
+	r.count++
+	r.mu.Unlock()
+}
+
+func (r *timeoutCountingRegistry) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true
@@ -237,6 +261,232 @@ func TestSubscriber_GetClientID(t *testing.T) {
 	}
 }
 
+func TestSubscriber_Nack_ExpiresDeadlineImmediately(t *testing.T) {
+	conn, cleanup := createTestWebSocket()
+	defer cleanup()
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.TrackInFlight(InFlight{
+		Topic:    "orders",
+		Msg:      models.Message{ID: "m1"},
+		Attempts: 1,
+		Deadline: time.Now().Add(time.Hour),
+	})
+
+	if !sub.Nack("m1") {
+		t.Fatal("expected Nack to find the in-flight message")
+	}
+	if sub.Nack("missing") {
+		t.Error("expected Nack on an unknown ID to return false")
+	}
+
+	expired := sub.ExpiredInFlight(time.Now())
+	if len(expired) != 1 || expired[0].Msg.ID != "m1" {
+		t.Fatalf("expected nacked message to be immediately expired, got %+v", expired)
+	}
+}
+
+func TestSubscriber_ModAck_ExtendsDeadline(t *testing.T) {
+	conn, cleanup := createTestWebSocket()
+	defer cleanup()
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.TrackInFlight(InFlight{
+		Topic:    "orders",
+		Msg:      models.Message{ID: "m1"},
+		Attempts: 1,
+		Deadline: time.Now().Add(time.Millisecond),
+	})
+
+	newDeadline := time.Now().Add(time.Hour)
+	if !sub.ModAck("m1", newDeadline) {
+		t.Fatal("expected ModAck to find the in-flight message")
+	}
+	if sub.ModAck("missing", newDeadline) {
+		t.Error("expected ModAck on an unknown ID to return false")
+	}
+
+	if expired := sub.ExpiredInFlight(time.Now().Add(time.Second)); len(expired) != 0 {
+		t.Errorf("expected extended deadline to survive, got expired %+v", expired)
+	}
+}
+
+func TestSubscriber_Keepalive_RecordsPong(t *testing.T) {
+	// createTestWebSocket's server never reads, so it can't answer our
+	// pings; use a server with a read loop so gorilla's default ping
+	// handler actually sends a pong back.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.SetKeepalive(20*time.Millisecond, 200*time.Millisecond)
+
+	if !sub.LastPongAt().IsZero() {
+		t.Fatal("expected no pong recorded before StartWriter runs")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub.StartWriter(ctx, 100*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if sub.LastPongAt().IsZero() {
+		t.Error("expected a pong to have been recorded by now")
+	}
+	if !sub.IsActive() {
+		t.Error("subscriber should still be active while pongs keep arriving")
+	}
+}
+
+func TestSubscriber_Keepalive_MissedPongMarksInactive(t *testing.T) {
+	// A server that upgrades but never reads, so it never sees our pings
+	// and never sends a pong back.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := upgrader.Upgrade(w, r, nil); err != nil {
+			http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.SetKeepalive(time.Hour, 30*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub.StartWriter(ctx, 100*time.Millisecond)
+
+	time.Sleep(150 * time.Millisecond)
+
+	if sub.IsActive() {
+		t.Error("expected subscriber to be marked inactive after missing its pong deadline")
+	}
+}
+
+func TestSubscriber_Keepalive_MissedPongReportsTimeoutMetric(t *testing.T) {
+	// A server that upgrades but never reads, so it never sees our pings
+	// and never sends a pong back.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := upgrader.Upgrade(w, r, nil); err != nil {
+			http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	reg := &timeoutCountingRegistry{Registry: metrics.Noop()}
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.SetMetricsRegistry(reg, "orders")
+	sub.SetKeepalive(time.Hour, 30*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub.StartWriter(ctx, 100*time.Millisecond)
+
+	time.Sleep(150 * time.Millisecond)
+
+	if sub.IsActive() {
+		t.Error("expected subscriber to be marked inactive after missing its pong deadline")
+	}
+	if reg.Count() == 0 {
+		t.Error("expected the missed pong to report a timeout disconnect metric")
+	}
+}
+
+// recordingHandler is a MessageHandler that records every frame it's given,
+// for asserting on StartReader's dispatch.
+type recordingHandler struct {
+	mu  sync.Mutex
+	got []models.WSClientMsg
+}
+
+func (h *recordingHandler) HandleClientMessage(s *Subscriber, msg models.WSClientMsg) {
+	h.mu.Lock()
+	h.got = append(h.got, msg)
+	h.mu.Unlock()
+}
+
+func (h *recordingHandler) messages() []models.WSClientMsg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]models.WSClientMsg(nil), h.got...)
+}
+
+func TestSubscriber_StartReader_DispatchesAckAndHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			http.Error(w, "WebSocket upgrade failed", http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(models.WSClientMsg{Type: "ack", ID: "msg-1"})
+		conn.WriteJSON(models.WSClientMsg{Type: "response", RequestID: "req-1"})
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	sub := NewSubscriber("test-client", conn, 10)
+	sub.TrackInFlight(InFlight{Msg: models.Message{ID: "msg-1"}, Deadline: time.Now().Add(time.Hour)})
+
+	handler := &recordingHandler{}
+	sub.SetMessageHandler(handler)
+
+	go sub.StartReader()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if sub.Ack("msg-1") {
+		t.Error("expected the in-flight message to already be acked by StartReader's ack dispatch")
+	}
+
+	got := handler.messages()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 message dispatched to the handler, got %d", len(got))
+	}
+	if got[0].Type != "response" || got[0].RequestID != "req-1" {
+		t.Errorf("expected the response frame to be dispatched, got %+v", got[0])
+	}
+}
+
 func BenchmarkSubscriber_SendMessage(b *testing.B) {
 	conn, cleanup := createTestWebSocket()
 	defer cleanup()
@@ -249,3 +499,79 @@ func BenchmarkSubscriber_SendMessage(b *testing.B) {
 		sub.SendMessage(*msg)
 	}
 }
+
+func TestSubscriber_RetryPolicy_DefaultsToZeroValue(t *testing.T) {
+	sub := NewSubscriber("test-client", nil, 10)
+
+	policy := sub.RetryPolicy()
+	if policy.MaxAttempts != 0 {
+		t.Errorf("expected no retry policy set, got MaxAttempts=%d", policy.MaxAttempts)
+	}
+
+	sub.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Second, Strategy: RetryFixed})
+	policy = sub.RetryPolicy()
+	if policy.MaxAttempts != 3 || policy.Backoff != time.Second || policy.Strategy != RetryFixed {
+		t.Errorf("RetryPolicy() = %+v, want MaxAttempts=3 Backoff=1s Strategy=fixed", policy)
+	}
+}
+
+func TestRetryPolicy_NextBackoff_Fixed(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: time.Second, Strategy: RetryFixed}
+
+	for attempt := 1; attempt <= 4; attempt++ {
+		if got := policy.NextBackoff(attempt); got != time.Second {
+			t.Errorf("NextBackoff(%d) = %v, want 1s", attempt, got)
+		}
+	}
+}
+
+func TestRetryPolicy_NextBackoff_Exponential(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, Strategy: RetryExponential}
+
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+	}
+	for i, w := range want {
+		attempt := i + 1
+		if got := policy.NextBackoff(attempt); got != w {
+			t.Errorf("NextBackoff(%d) = %v, want %v", attempt, got, w)
+		}
+	}
+}
+
+// benchmarkCodecs compares the throughput of the three Codec
+// implementations to justify the msgpack dependency: JSON (the default),
+// Msgpack, and Msgpack wrapped in gzip compression.
+func benchmarkCodecs(b *testing.B, c codec.Codec) {
+	msg := models.ServerMsg{
+		Type:  "message",
+		Topic: "benchmark",
+		Message: &models.Message{
+			ID:         "m1",
+			Payload:    []byte(`{"value":42,"label":"benchmark payload"}`),
+			Attributes: map[string]string{"priority": "high", "region": "us"},
+		},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.Marshal(msg); err != nil {
+			b.Fatalf("Marshal failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkCodec_JSON(b *testing.B) {
+	benchmarkCodecs(b, codec.JSON)
+}
+
+func BenchmarkCodec_Msgpack(b *testing.B) {
+	benchmarkCodecs(b, codec.Msgpack)
+}
+
+func BenchmarkCodec_MsgpackGzip(b *testing.B) {
+	benchmarkCodecs(b, codec.CompressedCodec{Inner: codec.Msgpack, Enc: compress.EncodingGzip, MinSize: 0})
+}
@@ -0,0 +1,270 @@
+package topic
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+)
+
+// OverflowPolicy decides how a message is delivered to a subscriber,
+// including what happens when the subscriber's Send buffer is full. It owns
+// the whole delivery attempt (not just the overflow case) so modes like
+// AckMode can track every message it hands off, not only the ones that hit
+// a full buffer.
+type OverflowPolicy interface {
+	// Name identifies the policy, e.g. for metrics and config.
+	Name() string
+
+	// Deliver attempts to hand msg to sub. It returns true if the message
+	// was delivered (or, for AckMode, accepted for at-least-once delivery),
+	// false if it was dropped.
+	Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool
+}
+
+func serverMsg(topicName string, msg models.Message) models.ServerMsg {
+	return models.ServerMsg{
+		Type:    "message",
+		Topic:   topicName,
+		Message: &msg,
+		Ts:      time.Now(),
+	}
+}
+
+// dropOldestPolicy drops the oldest buffered message to make room for the
+// new one when the subscriber's Send buffer is full.
+type dropOldestPolicy struct{}
+
+// DropOldest returns an OverflowPolicy that makes room for a new message by
+// discarding the oldest one still buffered for the subscriber.
+func DropOldest() OverflowPolicy { return dropOldestPolicy{} }
+
+func (dropOldestPolicy) Name() string { return "DROP_OLDEST" }
+
+func (p dropOldestPolicy) Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool {
+	sm := serverMsg(t.Name, msg)
+	select {
+	case sub.Send <- sm:
+		return true
+	default:
+	}
+
+	// Buffer full: drain one message to make room, then retry once.
+	select {
+	case <-sub.Send:
+	default:
+	}
+
+	select {
+	case sub.Send <- sm:
+		atomic.AddUint64(&t.dropped, 1)
+		return true
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+		return false
+	}
+}
+
+// dropNewestPolicy discards the message just published when the
+// subscriber's buffer is full, keeping whatever it already has queued.
+type dropNewestPolicy struct{}
+
+// DropNewest returns an OverflowPolicy that discards the new message
+// instead of displacing what the subscriber already has buffered.
+func DropNewest() OverflowPolicy { return dropNewestPolicy{} }
+
+func (dropNewestPolicy) Name() string { return "DROP_NEWEST" }
+
+func (p dropNewestPolicy) Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool {
+	select {
+	case sub.Send <- serverMsg(t.Name, msg):
+		return true
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+		return false
+	}
+}
+
+// blockWithTimeoutPolicy blocks the publisher until the subscriber drains
+// space or the timeout elapses.
+type blockWithTimeoutPolicy struct {
+	timeout time.Duration
+}
+
+// BlockWithTimeout returns an OverflowPolicy that blocks delivery until the
+// subscriber has room or timeout elapses, whichever comes first. A
+// non-positive timeout falls back to a 5 second default.
+func BlockWithTimeout(timeout time.Duration) OverflowPolicy {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return blockWithTimeoutPolicy{timeout: timeout}
+}
+
+func (blockWithTimeoutPolicy) Name() string { return "BLOCK" }
+
+func (p blockWithTimeoutPolicy) Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool {
+	select {
+	case sub.Send <- serverMsg(t.Name, msg):
+		return true
+	case <-time.After(p.timeout):
+		atomic.AddUint64(&t.dropped, 1)
+		return false
+	}
+}
+
+// disconnectPolicy closes the subscriber when its buffer is full, after
+// trying to warn it with an error message.
+type disconnectPolicy struct{}
+
+// Disconnect returns an OverflowPolicy that disconnects a subscriber whose
+// buffer is full instead of dropping a message.
+func Disconnect() OverflowPolicy { return disconnectPolicy{} }
+
+func (disconnectPolicy) Name() string { return "DISCONNECT" }
+
+func (p disconnectPolicy) Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool {
+	select {
+	case sub.Send <- serverMsg(t.Name, msg):
+		return true
+	default:
+	}
+
+	// Buffer full: warn the client if we can, then tear down the subscriber.
+	errMsg := models.NewServerError("", "BUFFER_OVERFLOW", "Subscriber buffer overflow, disconnecting")
+	select {
+	case sub.Send <- *errMsg:
+	default:
+	}
+	sub.Close()
+	t.RemoveSubscriber(sub.GetClientID())
+
+	atomic.AddUint64(&t.dropped, 1)
+	return false
+}
+
+// DefaultAckTimeout is how long an AckMode delivery waits for an
+// acknowledgement before it is considered for redelivery.
+const DefaultAckTimeout = 30 * time.Second
+
+// DefaultMaxDeliveryAttempts is how many times in all (the first delivery
+// plus redeliveries) AckMode delivers a message before dead-lettering it.
+const DefaultMaxDeliveryAttempts = 5
+
+// AckModePolicy implements at-least-once delivery: every message handed to
+// a subscriber is tracked in-flight until the client acknowledges it by
+// message ID, or it is redelivered after ackTimeout until it has been
+// delivered maxDeliveryAttempts times in all, after which it is
+// dead-lettered to "dlq.<topic>".
+//
+// Reconnects under the same ClientID inherit the previous connection's
+// in-flight messages (see Topic.AddSubscriber); a subscriber that never
+// reconnects loses its in-flight state once RemoveSubscriber runs, since
+// there is no separate grace-period timer beyond that handoff.
+type AckModePolicy struct {
+	ackTimeout          time.Duration
+	maxDeliveryAttempts int
+}
+
+// AckMode returns an OverflowPolicy providing acknowledgement-based
+// at-least-once delivery. ackTimeout and maxDeliveryAttempts fall back to
+// DefaultAckTimeout and DefaultMaxDeliveryAttempts when non-positive.
+func AckMode(ackTimeout time.Duration, maxDeliveryAttempts int) *AckModePolicy {
+	if ackTimeout <= 0 {
+		ackTimeout = DefaultAckTimeout
+	}
+	if maxDeliveryAttempts <= 0 {
+		maxDeliveryAttempts = DefaultMaxDeliveryAttempts
+	}
+	return &AckModePolicy{ackTimeout: ackTimeout, maxDeliveryAttempts: maxDeliveryAttempts}
+}
+
+func (p *AckModePolicy) Name() string { return "ACK" }
+
+func (p *AckModePolicy) Deliver(t *Topic, sub *subscriber.Subscriber, msg models.Message) bool {
+	select {
+	case sub.Send <- serverMsg(t.Name, msg):
+		sub.TrackInFlight(subscriber.InFlight{
+			Topic:    t.Name,
+			Msg:      msg,
+			Attempts: 1,
+			Deadline: time.Now().Add(p.ackTimeout),
+		})
+		return true
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+		return false
+	}
+}
+
+// RedeliverExpired scans t's active subscribers for in-flight messages past
+// their ack deadline. Messages under maxDeliveryAttempts are redelivered
+// with a fresh deadline; messages that have exhausted their attempts are
+// handed to deadLetter, named "dlq.<topicName>", instead.
+func (p *AckModePolicy) RedeliverExpired(t *Topic, deadLetter func(topicName string, msg models.Message)) {
+	now := time.Now()
+
+	t.subsMu.RLock()
+	subs := make([]*subscriber.Subscriber, 0, len(t.subs))
+	for _, sub := range t.subs {
+		subs = append(subs, sub)
+	}
+	t.subsMu.RUnlock()
+
+	for _, sub := range subs {
+		if !sub.IsActive() {
+			continue
+		}
+
+		for _, rec := range sub.ExpiredInFlight(now) {
+			if rec.Attempts >= p.maxDeliveryAttempts {
+				atomic.AddUint64(&t.deadLettered, 1)
+				if deadLetter != nil {
+					deadLetter(DLQTopicName(rec.Topic), rec.Msg)
+				}
+				continue
+			}
+
+			select {
+			case sub.Send <- serverMsg(rec.Topic, rec.Msg):
+			default:
+			}
+			atomic.AddUint64(&t.redelivered, 1)
+			sub.TrackInFlight(subscriber.InFlight{
+				Topic:    rec.Topic,
+				Msg:      rec.Msg,
+				Attempts: rec.Attempts + 1,
+				Deadline: now.Add(p.ackTimeout),
+			})
+		}
+	}
+}
+
+// DLQTopicName returns the dead-letter topic name for topicName.
+func DLQTopicName(topicName string) string {
+	return "dlq." + topicName
+}
+
+// PolicyFromName resolves a config.Config.DefaultPublishPolicy name
+// ("DROP_OLDEST", "DROP_NEWEST", "BLOCK", "DISCONNECT", or "ACK") into the
+// corresponding OverflowPolicy. blockTimeout, ackTimeout, and
+// maxDeliveryAttempts parameterize BLOCK and ACK respectively and are
+// ignored otherwise. Returns an error for an unrecognized name.
+func PolicyFromName(name string, blockTimeout, ackTimeout time.Duration, maxDeliveryAttempts int) (OverflowPolicy, error) {
+	switch name {
+	case "DROP_OLDEST", "":
+		return DropOldest(), nil
+	case "DROP_NEWEST":
+		return DropNewest(), nil
+	case "BLOCK":
+		return BlockWithTimeout(blockTimeout), nil
+	case "DISCONNECT":
+		return Disconnect(), nil
+	case "ACK":
+		return AckMode(ackTimeout, maxDeliveryAttempts), nil
+	default:
+		return nil, fmt.Errorf("unknown publish policy %q", name)
+	}
+}
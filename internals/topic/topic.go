@@ -2,33 +2,79 @@
 package topic
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/filter"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/ringbuffer"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
 )
 
-const (
-	// PolicyDropOldest drops the oldest message when subscriber buffer is full
-	PolicyDropOldest = "DROP_OLDEST"
-	// PolicyDisconnect disconnects the subscriber when buffer is full
-	PolicyDisconnect = "DISCONNECT"
+// tracer emits spans around Publish and per-subscriber delivery so
+// operators can see slow consumers in a trace backend. It has no effect
+// unless the process configures an OpenTelemetry SDK/exporter; by default
+// the global otel tracer provider is a no-op.
+var tracer = otel.Tracer("github.com/tanmay-xvx/inmem-pubsub/internals/topic")
+
+var (
+	// PolicyDropOldest is the default OverflowPolicy: drop the oldest
+	// buffered message to make room for a new one.
+	PolicyDropOldest = DropOldest()
+	// PolicyDisconnect is an OverflowPolicy that disconnects a subscriber
+	// instead of dropping a message when its buffer is full.
+	PolicyDisconnect = Disconnect()
 )
 
 // Topic represents a named channel for publishing and subscribing to messages.
 // It manages subscribers and maintains a ring buffer of recent messages.
 type Topic struct {
-	Name     string
-	subs     map[string]*subscriber.Subscriber
-	subsMu   sync.RWMutex
-	ring     *ringbuffer.RingBuffer
-	messages uint64 // atomic counter for total messages published
-	dropped  uint64 // atomic counter for dropped messages
+	Name         string
+	subs         map[string]*subscriber.Subscriber
+	subsMu       sync.RWMutex
+	ring         *ringbuffer.RingBuffer
+	messages     uint64 // atomic counter for total messages published
+	dropped      uint64 // atomic counter for dropped messages
+	filtered     uint64 // atomic counter for messages a subscriber's filter rejected
+	redelivered  uint64 // atomic counter for redeliveries (AckMode, or the registry's overflow retryScheduler)
+	deadLettered uint64 // atomic counter for deliveries sent to the dead-letter topic
+
+	ttl          int64 // atomic: time.Duration nanoseconds; zero means no TTL
+	lastActivity int64 // atomic: UnixNano of the last publish or subscribe
+
+	metricsReg metrics.Registry
+
+	// msgHandler, if set, is wired into every subscriber added to this
+	// topic so its reader goroutine can dispatch inbound frames (e.g. a
+	// "response" reply to a Registry.Request call) beyond the ack/nack/
+	// modack handling Subscriber already does itself. nil means no
+	// dispatch; see SetMessageHandler.
+	msgHandler subscriber.MessageHandler
+
+	// dropHandler, if set, is notified whenever Publish or PublishBatch
+	// fails to deliver a message to one of this topic's own subscribers
+	// because its buffer overflowed, so a caller (the registry's DLQ
+	// subsystem) can react per subscriber. It is not consulted by
+	// DeliverTo, which delivers to subscribers matched via a
+	// hierarchical/wildcard pattern rather than this topic's own
+	// subscriber map, and is also reused internally to redeliver a
+	// scheduled retry. nil (the default) disables reporting; see
+	// SetDropHandler.
+	dropHandler DropHandler
 }
 
+// DropHandler is notified when Publish or PublishBatch drops a message for
+// one of a topic's own subscribers because its buffer overflowed. See
+// Topic.SetDropHandler.
+type DropHandler func(sub *subscriber.Subscriber, msg models.Message)
+
 // NewTopic creates a new topic with the specified name and ring buffer capacity.
 func NewTopic(name string, ringCap int) *Topic {
 	if ringCap <= 0 {
@@ -36,12 +82,84 @@ func NewTopic(name string, ringCap int) *Topic {
 	}
 
 	return &Topic{
-		Name: name,
-		subs: make(map[string]*subscriber.Subscriber),
-		ring: ringbuffer.NewRingBuffer(ringCap),
+		Name:         name,
+		subs:         make(map[string]*subscriber.Subscriber),
+		ring:         ringbuffer.NewRingBuffer(ringCap),
+		lastActivity: time.Now().UnixNano(),
+		metricsReg:   metrics.Noop(),
+	}
+}
+
+// NewTopicWithRing creates a new topic backed by a caller-provided ring
+// buffer, e.g. one returned by ringbuffer.NewRingBufferWithWAL, so the
+// topic's message history can be made durable.
+func NewTopicWithRing(name string, ring *ringbuffer.RingBuffer) *Topic {
+	return &Topic{
+		Name:         name,
+		subs:         make(map[string]*subscriber.Subscriber),
+		ring:         ring,
+		lastActivity: time.Now().UnixNano(),
+		metricsReg:   metrics.Noop(),
 	}
 }
 
+// SetMetricsRegistry wires reg into the topic so Publish, AddSubscriber,
+// and RemoveSubscriber report through it. Defaults to metrics.Noop.
+func (t *Topic) SetMetricsRegistry(reg metrics.Registry) {
+	if reg == nil {
+		reg = metrics.Noop()
+	}
+	t.metricsReg = reg
+}
+
+// SetDropHandler wires h into the topic so every subsequent buffer-overflow
+// drop in Publish/PublishBatch is reported to it. A nil h (the default)
+// disables reporting.
+func (t *Topic) SetDropHandler(h DropHandler) {
+	t.dropHandler = h
+}
+
+// SetMessageHandler wires h into the topic so every subscriber added from
+// this point on has its reader dispatch inbound frames to h (see
+// subscriber.Subscriber.SetMessageHandler). A nil h disables dispatch.
+func (t *Topic) SetMessageHandler(h subscriber.MessageHandler) {
+	t.msgHandler = h
+}
+
+// SetTTL sets the topic's idle-expiry duration. A zero TTL (the default)
+// means the topic is never reaped for being idle.
+func (t *Topic) SetTTL(ttl time.Duration) {
+	atomic.StoreInt64(&t.ttl, int64(ttl))
+}
+
+// GetTTL returns the topic's idle-expiry duration, or zero if unset.
+func (t *Topic) GetTTL() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.ttl))
+}
+
+// GetLastActivity returns the time of the topic's last publish or subscribe.
+func (t *Topic) GetLastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&t.lastActivity))
+}
+
+// touchActivity records that the topic was just published to or subscribed.
+func (t *Topic) touchActivity() {
+	atomic.StoreInt64(&t.lastActivity, time.Now().UnixNano())
+}
+
+// IsExpired reports whether the topic has a TTL set, has had no activity
+// for longer than that TTL as of now, and has no active subscribers.
+func (t *Topic) IsExpired(now time.Time) bool {
+	ttl := t.GetTTL()
+	if ttl <= 0 {
+		return false
+	}
+	if now.Sub(t.GetLastActivity()) < ttl {
+		return false
+	}
+	return t.GetSubscriberCount() == 0
+}
+
 // AddSubscriber adds a subscriber to the topic.
 // If a subscriber with the same ClientID already exists, it will be replaced.
 func (t *Topic) AddSubscriber(s *subscriber.Subscriber) {
@@ -52,12 +170,24 @@ func (t *Topic) AddSubscriber(s *subscriber.Subscriber) {
 	t.subsMu.Lock()
 	defer t.subsMu.Unlock()
 
-	// If subscriber already exists, close it first
+	// If subscriber already exists, hand off any unacknowledged AckMode
+	// messages to the reconnecting subscriber before closing it, so a brief
+	// disconnect doesn't lose at-least-once delivery guarantees.
 	if existing, exists := t.subs[s.ClientID]; exists {
+		for _, rec := range existing.DrainInFlight() {
+			s.TrackInFlight(rec)
+		}
 		existing.Close()
 	}
 
+	s.SetMetricsRegistry(t.metricsReg, t.Name)
+	if t.msgHandler != nil {
+		s.SetMessageHandler(t.msgHandler)
+	}
 	t.subs[s.ClientID] = s
+	t.touchActivity()
+	t.metricsReg.SetBufferDepth(t.Name, s.ClientID, len(s.Send))
+	t.metricsReg.SetSubscribers(t.Name, len(t.subs))
 }
 
 // RemoveSubscriber removes a subscriber from the topic by client ID.
@@ -69,131 +199,194 @@ func (t *Topic) RemoveSubscriber(clientID string) bool {
 	if sub, exists := t.subs[clientID]; exists {
 		sub.Close()
 		delete(t.subs, clientID)
+		t.touchActivity()
+		t.metricsReg.DeleteBufferDepth(t.Name, clientID)
+		t.metricsReg.SetSubscribers(t.Name, len(t.subs))
 		return true
 	}
 
 	return false
 }
 
-// Publish publishes a message to the topic and delivers it to all subscribers.
-// The policy parameter determines how to handle subscriber buffer overflow.
-// Returns the number of messages delivered and dropped for metrics.
-func (t *Topic) Publish(msg models.Message, policy string, wsBuf int) (delivered int, dropped int) {
+// Publish publishes a message to the topic and delivers it to all
+// subscribers according to policy, which decides how each delivery is
+// attempted and what happens if a subscriber's buffer is full (see
+// OverflowPolicy). Returns the number of messages delivered and dropped for
+// metrics.
+func (t *Topic) Publish(msg models.Message, policy OverflowPolicy) (delivered int, dropped int) {
+	ctx, span := tracer.Start(context.Background(), "topic.Publish",
+		trace.WithAttributes(
+			attribute.String("pubsub.topic", t.Name),
+			attribute.String("pubsub.message_id", msg.ID),
+		))
+	defer span.End()
+
+	start := time.Now()
+
 	// Push message to ring buffer
 	t.ring.Push(msg)
 
 	// Atomically increment message counter
 	atomic.AddUint64(&t.messages, 1)
+	t.touchActivity()
+	t.metricsReg.ObservePublished(t.Name)
 
 	// Get all active subscribers
-	t.subsMu.RLock()
-	subscribers := make([]*subscriber.Subscriber, 0, len(t.subs))
-	for _, sub := range t.subs {
-		if sub.IsActive() {
-			subscribers = append(subscribers, sub)
-		}
-	}
-	t.subsMu.RUnlock()
+	subscribers := t.activeSubscribers()
+
+	// Decode the payload once for this publish and share it across every
+	// subscriber's filter, instead of each one re-decoding the same JSON.
+	filterCtx := filter.NewContext(msg, t.Name, start)
 
 	// Deliver message to all subscribers
+	fanoutStart := time.Now()
 	for _, sub := range subscribers {
-		if t.deliverToSubscriber(sub, msg, policy, wsBuf) {
+		if !sub.Match(filterCtx) {
+			atomic.AddUint64(&t.filtered, 1)
+			t.metricsReg.ObserveFiltered(t.Name, sub.GetClientID())
+			continue
+		}
+
+		if t.deliverTraced(ctx, sub, msg, policy) {
 			delivered++
+			t.metricsReg.ObserveDelivered(t.Name, sub.GetClientID())
 		} else {
 			dropped++
+			t.metricsReg.ObserveDropped(t.Name, policy.Name())
+			if t.dropHandler != nil {
+				t.dropHandler(sub, msg)
+			}
 		}
+		t.metricsReg.SetBufferDepth(t.Name, sub.GetClientID(), len(sub.Send))
 	}
+	t.metricsReg.ObserveFanoutLatency(t.Name, time.Since(fanoutStart))
 
+	t.metricsReg.ObservePublishLatency(t.Name, time.Since(start))
 	return delivered, dropped
 }
 
-// deliverToSubscriber attempts to deliver a message to a single subscriber.
-// Returns true if message was delivered, false if it was dropped.
-func (t *Topic) deliverToSubscriber(sub *subscriber.Subscriber, msg models.Message, policy string, wsBuf int) bool {
-	// Convert Message to ServerMsg
-	serverMsg := models.ServerMsg{
-		Type:    "message",
-		Topic:   t.Name,
-		Message: &msg,
-		Ts:      time.Now(),
-	}
+// activeSubscribers snapshots every subscriber currently accepting
+// deliveries, taking subsMu only for the duration of the snapshot.
+func (t *Topic) activeSubscribers() []*subscriber.Subscriber {
+	t.subsMu.RLock()
+	defer t.subsMu.RUnlock()
 
-	// Try to send message non-blocking
-	select {
-	case sub.Send <- serverMsg:
-		return true
-	default:
-		// Buffer is full, handle according to policy
-		return t.handleBufferOverflow(sub, msg, policy, wsBuf)
+	subs := make([]*subscriber.Subscriber, 0, len(t.subs))
+	for _, sub := range t.subs {
+		if sub.IsActive() {
+			subs = append(subs, sub)
+		}
 	}
+	return subs
+}
+
+// BatchResult reports how a single message within a PublishBatch call
+// fared, so a caller that needs per-message delivered/dropped counts
+// (e.g. the registry's publish Bundler) doesn't have to re-derive them
+// from a single summed total.
+type BatchResult struct {
+	Delivered int
+	Dropped   int
 }
 
-// handleBufferOverflow handles subscriber buffer overflow according to the specified policy.
-// Returns true if message was delivered, false if it was dropped.
-func (t *Topic) handleBufferOverflow(sub *subscriber.Subscriber, msg models.Message, policy string, wsBuf int) bool {
-	switch policy {
-	case PolicyDropOldest:
-		return t.dropOldestAndSend(sub, msg)
+// PublishBatch publishes a slice of messages in one fan-out pass: it
+// snapshots the subscriber list once, then for every subscriber attempts
+// delivery of every message in msgs, instead of paying the subsMu.RLock
+// and snapshot cost once per message the way msgs-many Publish calls
+// would. It is the batched counterpart of Publish, used by the registry's
+// publish Bundler to amortize fan-out cost under high write rates.
+// Returns one BatchResult per message in msgs, in the same order.
+func (t *Topic) PublishBatch(msgs []models.Message, policy OverflowPolicy) []BatchResult {
+	results := make([]BatchResult, len(msgs))
+	if len(msgs) == 0 {
+		return results
+	}
+
+	ctx, span := tracer.Start(context.Background(), "topic.PublishBatch",
+		trace.WithAttributes(
+			attribute.String("pubsub.topic", t.Name),
+			attribute.Int("pubsub.batch_size", len(msgs)),
+		))
+	defer span.End()
 
-	case PolicyDisconnect:
-		return t.disconnectAndSend(sub, msg)
+	start := time.Now()
 
-	default:
-		// Default to drop oldest
-		return t.dropOldestAndSend(sub, msg)
+	for i := range msgs {
+		t.ring.Push(msgs[i])
+		t.metricsReg.ObservePublished(t.Name)
 	}
-}
+	atomic.AddUint64(&t.messages, uint64(len(msgs)))
+	t.touchActivity()
 
-// dropOldestAndSend implements DROP_OLDEST policy.
-// Drains one message from subscriber buffer and sends the new message.
-func (t *Topic) dropOldestAndSend(sub *subscriber.Subscriber, msg models.Message) bool {
-	// Try to drain one message from the buffer (non-blocking)
-	select {
-	case <-sub.Send:
-		// Successfully drained one message, now try to send the new one
-		// Convert Message to ServerMsg
-		serverMsg := models.ServerMsg{
-			Type:    "message",
-			Topic:   t.Name,
-			Message: &msg,
-			Ts:      time.Now(),
-		}
-		select {
-		case sub.Send <- serverMsg:
-			atomic.AddUint64(&t.dropped, 1)
-			return true
-		default:
-			// Still can't send, increment dropped counter
-			atomic.AddUint64(&t.dropped, 1)
-			return false
+	subscribers := t.activeSubscribers()
+	filterCtxs := make([]filter.Context, len(msgs))
+	for i := range msgs {
+		filterCtxs[i] = filter.NewContext(msgs[i], t.Name, start)
+	}
+
+	fanoutStart := time.Now()
+	for _, sub := range subscribers {
+		for i := range msgs {
+			if !sub.Match(filterCtxs[i]) {
+				atomic.AddUint64(&t.filtered, 1)
+				t.metricsReg.ObserveFiltered(t.Name, sub.GetClientID())
+				continue
+			}
+
+			if t.deliverTraced(ctx, sub, msgs[i], policy) {
+				results[i].Delivered++
+				t.metricsReg.ObserveDelivered(t.Name, sub.GetClientID())
+			} else {
+				results[i].Dropped++
+				t.metricsReg.ObserveDropped(t.Name, policy.Name())
+				if t.dropHandler != nil {
+					t.dropHandler(sub, msgs[i])
+				}
+			}
 		}
-	default:
-		// Can't drain, increment dropped counter
-		atomic.AddUint64(&t.dropped, 1)
-		return false
+		t.metricsReg.SetBufferDepth(t.Name, sub.GetClientID(), len(sub.Send))
 	}
+	t.metricsReg.ObserveFanoutLatency(t.Name, time.Since(fanoutStart))
+	t.metricsReg.ObservePublishLatency(t.Name, time.Since(start))
+
+	return results
 }
 
-// disconnectAndSend implements DISCONNECT policy.
-// Sends an error message and closes the subscriber.
-func (t *Topic) disconnectAndSend(sub *subscriber.Subscriber, msg models.Message) bool {
-	// Try to send error message before disconnecting
-	errorMsg := models.NewServerError("", "BUFFER_OVERFLOW", "Subscriber buffer overflow, disconnecting")
-	select {
-	case sub.Send <- *errorMsg:
-		// Error message sent successfully
-	default:
-		// Can't even send error message
+// DeliverTo attempts delivery of msg to sub under policy, applying sub's
+// filter the same way Publish does, but without taking subsMu or touching
+// the ring buffer or message counters. It is used by the registry to
+// deliver to a subscriber matched via a hierarchical/wildcard topic
+// pattern (see registry.TopicTrie) rather than this Topic's own
+// subscriber map, while still sharing Publish's delivery semantics,
+// tracing, and drop accounting. Returns false (without attempting
+// delivery) if sub's filter rejects msg.
+func (t *Topic) DeliverTo(sub *subscriber.Subscriber, msg models.Message, policy OverflowPolicy) bool {
+	if !sub.Match(filter.NewContext(msg, t.Name, time.Now())) {
+		atomic.AddUint64(&t.filtered, 1)
+		t.metricsReg.ObserveFiltered(t.Name, sub.GetClientID())
+		return false
 	}
 
-	// Close the subscriber
-	sub.Close()
+	delivered := t.deliverTraced(context.Background(), sub, msg, policy)
+	if delivered {
+		t.metricsReg.ObserveDelivered(t.Name, sub.GetClientID())
+	} else {
+		t.metricsReg.ObserveDropped(t.Name, policy.Name())
+	}
+	t.metricsReg.SetBufferDepth(t.Name, sub.GetClientID(), len(sub.Send))
+	return delivered
+}
 
-	// Remove from topic
-	t.RemoveSubscriber(sub.GetClientID())
+// deliverTraced wraps policy.Deliver in a child span carrying the
+// subscriber's client ID, so a slow or misbehaving consumer shows up
+// clearly against the parent Publish span.
+func (t *Topic) deliverTraced(ctx context.Context, sub *subscriber.Subscriber, msg models.Message, policy OverflowPolicy) bool {
+	_, span := tracer.Start(ctx, "topic.deliver", trace.WithAttributes(
+		attribute.String("pubsub.client_id", sub.GetClientID()),
+	))
+	defer span.End()
 
-	atomic.AddUint64(&t.dropped, 1)
-	return false
+	return policy.Deliver(t, sub, msg)
 }
 
 // ListSubscriberIDs returns a slice of all active subscriber client IDs.
@@ -236,16 +429,103 @@ func (t *Topic) GetDroppedCount() uint64 {
 	return atomic.LoadUint64(&t.dropped)
 }
 
+// GetFilteredCount returns the total number of deliveries skipped because a
+// subscriber's filter rejected the message. Distinct from GetDroppedCount,
+// which counts deliveries lost to a full buffer.
+func (t *Topic) GetFilteredCount() uint64 {
+	return atomic.LoadUint64(&t.filtered)
+}
+
+// GetRedeliveredCount returns the total number of messages redelivered
+// after a failed delivery attempt: an AckMode message whose ack deadline
+// expired without an ack, or an overflow drop retried by the registry's
+// retryScheduler (see registry.Registry.SetDLQPolicy).
+func (t *Topic) GetRedeliveredCount() uint64 {
+	return atomic.LoadUint64(&t.redelivered)
+}
+
+// GetDeadLetteredCount returns the total number of messages sent to the
+// dead-letter topic after exhausting their delivery attempts: an AckMode
+// message, or an overflow drop whose subscriber.RetryPolicy ran out of
+// attempts.
+func (t *Topic) GetDeadLetteredCount() uint64 {
+	return atomic.LoadUint64(&t.deadLettered)
+}
+
+// IncRedelivered increments the redelivery counter reported by
+// GetRedeliveredCount. Used by the registry's retryScheduler when a
+// redelivery attempt for an overflow drop succeeds.
+func (t *Topic) IncRedelivered() {
+	atomic.AddUint64(&t.redelivered, 1)
+}
+
+// IncDeadLettered increments the dead-letter counter reported by
+// GetDeadLetteredCount. Used by the registry's retryScheduler when an
+// overflow drop's subscriber.RetryPolicy is exhausted.
+func (t *Topic) IncDeadLettered() {
+	atomic.AddUint64(&t.deadLettered, 1)
+}
+
 // GetLastN returns the last n messages from the ring buffer.
 func (t *Topic) GetLastN(n int) []models.Message {
 	return t.ring.LastN(n)
 }
 
+// GetSince returns every message buffered in the topic's ring buffer with
+// Seq >= seq, in chronological order, for resuming a subscription from a
+// sequence index.
+func (t *Topic) GetSince(seq uint64) []models.Message {
+	return t.ring.Since(seq)
+}
+
+// OldestSeq returns the sequence number of the oldest message still
+// retained in the topic's ring buffer, and false if none are retained.
+func (t *Topic) OldestSeq() (uint64, bool) {
+	return t.ring.OldestSeq()
+}
+
+// ReplayFrom returns every message with Seq >= seq, oldest first. When the
+// topic's ring buffer is WAL-backed and seq predates what the ring still
+// holds in memory, the missing prefix is read back from disk.
+func (t *Topic) ReplayFrom(seq uint64) ([]models.Message, error) {
+	return t.ring.ReplayFrom(seq)
+}
+
+// FirstSeq returns the sequence number of the oldest message this topic can
+// still replay, on disk or in memory, and false if it has published
+// nothing yet.
+func (t *Topic) FirstSeq() (uint64, bool) {
+	return t.ring.FirstSeq()
+}
+
+// LastSeq returns the sequence number of the most recently published
+// message, and false if the topic has published nothing yet.
+func (t *Topic) LastSeq() (uint64, bool) {
+	seq := t.ring.NextSeq()
+	if seq == 0 {
+		return 0, false
+	}
+	return seq, true
+}
+
+// TruncateBefore compacts the topic's on-disk WAL, if any, discarding
+// whole log segments that hold nothing at or after seq. It is a no-op for
+// a topic without a WAL-backed ring buffer.
+func (t *Topic) TruncateBefore(seq uint64) error {
+	return t.ring.TruncateBefore(seq)
+}
+
 // GetRingBufferSize returns the capacity of the ring buffer.
 func (t *Topic) GetRingBufferSize() int {
 	return t.ring.Capacity()
 }
 
+// CloseRing closes the topic's ring buffer, flushing and closing its WAL if
+// it has one. It is a no-op for ring buffers created without a WAL.
+func (t *Topic) CloseRing() error {
+	return t.ring.Close()
+}
+
 // GetSubscriber returns a subscriber by client ID.
 func (t *Topic) GetSubscriber(clientID string) (*subscriber.Subscriber, bool) {
 	t.subsMu.RLock()
@@ -122,8 +122,13 @@ func TestTopic_AddSubscriber(t *testing.T) {
 		t.Errorf("Expected 1 subscriber, got %d", topic.GetSubscriberCount())
 	}
 
-	// Test replacing existing subscriber
-	sub2, sub2Cleanup := createTestSubscriber("client-1", conn, 10)
+	// Test replacing existing subscriber. A real reconnect arrives on a
+	// fresh connection, not the old (about to be closed) one, so use a
+	// second conn here rather than reusing conn.
+	conn2, cleanup2 := createTestWebSocket()
+	defer cleanup2()
+
+	sub2, sub2Cleanup := createTestSubscriber("client-1", conn2, 10)
 	defer sub2Cleanup()
 
 	topic.AddSubscriber(sub2)
@@ -184,7 +189,7 @@ func TestTopic_Publish_Basic(t *testing.T) {
 		Payload: json.RawMessage(`{"test": "data"}`),
 	}
 
-	delivered, dropped := topic.Publish(msg, PolicyDropOldest, 10)
+	delivered, dropped := topic.Publish(msg, PolicyDropOldest)
 
 	if delivered != 1 {
 		t.Errorf("Expected 1 message delivered, got %d", delivered)
@@ -207,7 +212,7 @@ func TestTopic_Publish_NoSubscribers(t *testing.T) {
 		Payload: json.RawMessage(`{"test": "data"}`),
 	}
 
-	delivered, dropped := topic.Publish(msg, PolicyDropOldest, 10)
+	delivered, dropped := topic.Publish(msg, PolicyDropOldest)
 
 	if delivered != 0 {
 		t.Errorf("Expected 0 messages delivered, got %d", delivered)
@@ -242,7 +247,7 @@ func TestTopic_Publish_DropOldestPolicy(t *testing.T) {
 		Payload: json.RawMessage(`{"test": "data1"}`),
 	}
 
-	delivered, dropped := topic.Publish(msg1, PolicyDropOldest, 2)
+	delivered, dropped := topic.Publish(msg1, PolicyDropOldest)
 	if delivered != 1 || dropped != 0 {
 		t.Errorf("First message: delivered=%d, dropped=%d", delivered, dropped)
 	}
@@ -273,7 +278,7 @@ func TestTopic_Publish_DisconnectPolicy(t *testing.T) {
 		Payload: json.RawMessage(`{"test": "data1"}`),
 	}
 
-	delivered, dropped := topic.Publish(msg1, PolicyDisconnect, 2)
+	delivered, dropped := topic.Publish(msg1, PolicyDisconnect)
 	if delivered != 1 || dropped != 0 {
 		t.Errorf("First message: delivered=%d, dropped=%d", delivered, dropped)
 	}
@@ -286,13 +291,19 @@ func TestTopic_Publish_DisconnectPolicy(t *testing.T) {
 
 func TestTopic_ListSubscriberIDs(t *testing.T) {
 	topic := NewTopic("test-topic", 100)
-	conn, cleanup := createTestWebSocket()
-	defer cleanup()
+
+	// Each subscriber needs its own connection: StartWriter now runs a
+	// keepalive read pump on Conn, and two Subscribers must not call
+	// ReadMessage concurrently on the same *websocket.Conn.
+	conn1, cleanup1 := createTestWebSocket()
+	defer cleanup1()
+	conn2, cleanup2 := createTestWebSocket()
+	defer cleanup2()
 
 	// Add subscribers
-	sub1, sub1Cleanup := createTestSubscriber("client-1", conn, 10)
+	sub1, sub1Cleanup := createTestSubscriber("client-1", conn1, 10)
 	defer sub1Cleanup()
-	sub2, sub2Cleanup := createTestSubscriber("client-2", conn, 10)
+	sub2, sub2Cleanup := createTestSubscriber("client-2", conn2, 10)
 	defer sub2Cleanup()
 
 	topic.AddSubscriber(sub1)
@@ -328,7 +339,7 @@ func TestTopic_GetLastN(t *testing.T) {
 			ID:      fmt.Sprintf("msg-%d", i),
 			Payload: json.RawMessage(fmt.Sprintf(`{"value": %d}`, i)),
 		}
-		topic.Publish(msg, PolicyDropOldest, 10)
+		topic.Publish(msg, PolicyDropOldest)
 	}
 
 	// Get last 2 messages
@@ -383,7 +394,7 @@ func TestTopic_Concurrency(t *testing.T) {
 					ID:      fmt.Sprintf("goroutine-%d-msg-%d", id, j),
 					Payload: json.RawMessage(`{"goroutine": "test"}`),
 				}
-				topic.Publish(msg, PolicyDropOldest, 10)
+				topic.Publish(msg, PolicyDropOldest)
 			}
 		}(i)
 	}
@@ -397,6 +408,138 @@ func TestTopic_Concurrency(t *testing.T) {
 	}
 }
 
+func TestTopic_PublishBatch(t *testing.T) {
+	topic := NewTopic("test-topic", 100)
+	conn, cleanup := createTestWebSocket()
+	defer cleanup()
+
+	sub, subCleanup := createTestSubscriber("client-1", conn, 10)
+	defer subCleanup()
+	topic.AddSubscriber(sub)
+
+	time.Sleep(10 * time.Millisecond)
+
+	msgs := []models.Message{
+		{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)},
+		{ID: "msg-2", Payload: json.RawMessage(`{"v":2}`)},
+		{ID: "msg-3", Payload: json.RawMessage(`{"v":3}`)},
+	}
+
+	results := topic.PublishBatch(msgs, PolicyDropOldest)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Delivered != 1 || r.Dropped != 0 {
+			t.Errorf("message %d: expected delivered=1, dropped=0, got delivered=%d, dropped=%d", i, r.Delivered, r.Dropped)
+		}
+	}
+
+	if topic.GetMessageCount() != 3 {
+		t.Errorf("expected 3 total messages, got %d", topic.GetMessageCount())
+	}
+}
+
+func TestTopic_PublishBatch_Empty(t *testing.T) {
+	topic := NewTopic("test-topic", 100)
+
+	results := topic.PublishBatch(nil, PolicyDropOldest)
+	if len(results) != 0 {
+		t.Errorf("expected 0 results for an empty batch, got %d", len(results))
+	}
+	if topic.GetMessageCount() != 0 {
+		t.Errorf("expected 0 messages for an empty batch, got %d", topic.GetMessageCount())
+	}
+}
+
+func TestTopic_PublishBatch_NoSubscribers(t *testing.T) {
+	topic := NewTopic("test-topic", 100)
+
+	msgs := []models.Message{
+		{ID: "msg-1", Payload: json.RawMessage(`{}`)},
+		{ID: "msg-2", Payload: json.RawMessage(`{}`)},
+	}
+
+	results := topic.PublishBatch(msgs, PolicyDropOldest)
+	for i, r := range results {
+		if r.Delivered != 0 || r.Dropped != 0 {
+			t.Errorf("message %d: expected no delivery or drop with no subscribers, got delivered=%d, dropped=%d", i, r.Delivered, r.Dropped)
+		}
+	}
+	if topic.GetMessageCount() != 2 {
+		t.Errorf("expected 2 total messages, got %d", topic.GetMessageCount())
+	}
+}
+
+func TestTopic_DeliverTo(t *testing.T) {
+	topic := NewTopic("test-topic", 100)
+	conn, cleanup := createTestWebSocket()
+	defer cleanup()
+
+	sub, subCleanup := createTestSubscriber("client-1", conn, 10)
+	defer subCleanup()
+
+	time.Sleep(10 * time.Millisecond)
+
+	msg := models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)}
+
+	if !topic.DeliverTo(sub, msg, PolicyDropOldest) {
+		t.Fatal("expected DeliverTo to succeed")
+	}
+
+	// DeliverTo does not add sub to the topic's own subscriber map, nor
+	// does it push msg onto the ring buffer or bump the message count.
+	if topic.GetSubscriberCount() != 0 {
+		t.Errorf("expected DeliverTo not to register sub with the topic, got %d subscribers", topic.GetSubscriberCount())
+	}
+	if topic.GetMessageCount() != 0 {
+		t.Errorf("expected DeliverTo not to affect message count, got %d", topic.GetMessageCount())
+	}
+}
+
+func TestTopic_Publish_DropHandler(t *testing.T) {
+	top := NewTopic("test-topic", 100)
+
+	// A subscriber with no conn and a tiny, never-drained buffer
+	// deterministically overflows on the second publish.
+	sub := subscriber.NewSubscriber("client-1", nil, 1)
+	top.AddSubscriber(sub)
+
+	var mu sync.Mutex
+	var gotSub *subscriber.Subscriber
+	var gotMsg models.Message
+	calls := 0
+	top.SetDropHandler(func(s *subscriber.Subscriber, msg models.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		gotSub = s
+		gotMsg = msg
+	})
+
+	msg1 := models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)}
+	if delivered, dropped := top.Publish(msg1, PolicyDropOldest); delivered != 1 || dropped != 0 {
+		t.Fatalf("first publish: delivered=%d dropped=%d, want 1/0", delivered, dropped)
+	}
+
+	msg2 := models.Message{ID: "msg-2", Payload: json.RawMessage(`{"v":2}`)}
+	if delivered, dropped := top.Publish(msg2, DropNewest()); delivered != 0 || dropped != 1 {
+		t.Fatalf("second publish: delivered=%d dropped=%d, want 0/1", delivered, dropped)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("expected DropHandler to be called once, got %d", calls)
+	}
+	if gotSub != sub {
+		t.Errorf("DropHandler got subscriber %v, want %v", gotSub, sub)
+	}
+	if gotMsg.ID != msg2.ID {
+		t.Errorf("DropHandler got message %q, want %q", gotMsg.ID, msg2.ID)
+	}
+}
+
 func BenchmarkTopic_Publish(b *testing.B) {
 	topic := NewTopic("benchmark-topic", 1000)
 	msg := models.Message{
@@ -406,6 +549,6 @@ func BenchmarkTopic_Publish(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		topic.Publish(msg, PolicyDropOldest, 10)
+		topic.Publish(msg, PolicyDropOldest)
 	}
 }
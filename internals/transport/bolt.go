@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+// BoltTransport is a Transport backed by a bbolt database. Each topic gets
+// its own bucket, and messages are keyed by their big-endian sequence
+// number so history survives a restart and can be range-scanned for
+// from-index replay. Live delivery is fanned out in-memory on top of the
+// durable writes.
+type BoltTransport struct {
+	db *bolt.DB
+
+	mu   sync.RWMutex
+	subs map[string]map[string]chan models.Message // topic -> clientID -> channel
+}
+
+// NewBoltTransport opens (creating if necessary) a bbolt database at path.
+func NewBoltTransport(path string) (*BoltTransport, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transport: open bolt db %q: %w", path, err)
+	}
+
+	return &BoltTransport{
+		db:   db,
+		subs: make(map[string]map[string]chan models.Message),
+	}, nil
+}
+
+// seqKey encodes a sequence number as a big-endian byte slice so bucket
+// keys sort in sequence order.
+func seqKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// Publish implements Transport. It assigns the message the topic's next
+// sequence number, persists it to the topic's bucket, and fans it out to
+// any live subscribers, counting a subscriber whose channel is full as
+// dropped rather than blocking the publish on it.
+func (b *BoltTransport) Publish(topicName string, msg models.Message) (delivered, dropped int, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(topicName))
+		if err != nil {
+			return err
+		}
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		msg.Seq = seq
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(seqKey(seq), data)
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("transport: publish to topic %q: %w", topicName, err)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs[topicName] {
+		select {
+		case ch <- msg:
+			delivered++
+		default:
+			// Subscriber isn't keeping up; drop rather than block publish.
+			dropped++
+		}
+	}
+	return delivered, dropped, nil
+}
+
+// Subscribe implements Transport. The subscriber is registered for live
+// delivery before any requested history is read, so a message published in
+// between is, at worst, delivered twice; lastFlushed suppresses that
+// duplicate the same way the WebSocket subscribe path does.
+func (b *BoltTransport) Subscribe(ctx context.Context, topicName string, opts SubscribeOptions) (<-chan models.Message, error) {
+	clientID := opts.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("transport-%p", &opts)
+	}
+
+	bufSize := opts.BufferSize
+	if bufSize <= 0 {
+		bufSize = 100
+	}
+
+	live := make(chan models.Message, bufSize)
+	b.mu.Lock()
+	if b.subs[topicName] == nil {
+		b.subs[topicName] = make(map[string]chan models.Message)
+	}
+	b.subs[topicName][clientID] = live
+	b.mu.Unlock()
+
+	out := make(chan models.Message, bufSize)
+
+	var lastFlushed uint64
+	if opts.From > 0 {
+		history, err := b.History(topicName, opts.From, 0)
+		if err != nil {
+			b.unsubscribe(topicName, clientID)
+			return nil, err
+		}
+		for _, msg := range history {
+			out <- msg
+			if msg.Seq > lastFlushed {
+				lastFlushed = msg.Seq
+			}
+		}
+	}
+
+	go func(lastFlushed uint64) {
+		defer close(out)
+		defer b.unsubscribe(topicName, clientID)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-live:
+				if !ok {
+					return
+				}
+				if msg.Seq != 0 && msg.Seq <= lastFlushed {
+					continue
+				}
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}(lastFlushed)
+
+	return out, nil
+}
+
+func (b *BoltTransport) unsubscribe(topicName, clientID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if subs, ok := b.subs[topicName]; ok {
+		delete(subs, clientID)
+	}
+}
+
+// History implements Transport by range-scanning the topic's bucket from
+// seqKey(from) onward.
+func (b *BoltTransport) History(topicName string, from uint64, limit int) ([]models.Message, error) {
+	var msgs []models.Message
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(topicName))
+		if bucket == nil {
+			return nil
+		}
+
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(seqKey(from)); k != nil; k, v = cursor.Next() {
+			var msg models.Message
+			if err := json.Unmarshal(v, &msg); err != nil {
+				return fmt.Errorf("decode message at key %x: %w", k, err)
+			}
+			msgs = append(msgs, msg)
+			if limit > 0 && len(msgs) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transport: history for topic %q: %w", topicName, err)
+	}
+
+	return msgs, nil
+}
+
+// Close implements Transport by closing the underlying bbolt database.
+func (b *BoltTransport) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,122 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+)
+
+// MemoryTransport is a Transport backed by the in-memory registry. Message
+// history is whatever the topic's ring buffer retains (optionally persisted
+// to a WAL by the registry), so it does not survive a process restart
+// unless the registry is WAL-backed.
+type MemoryTransport struct {
+	registry *registry.Registry
+}
+
+// NewMemoryTransport creates a Transport that publishes and replays through
+// the given registry.
+func NewMemoryTransport(reg *registry.Registry) *MemoryTransport {
+	return &MemoryTransport{registry: reg}
+}
+
+// Publish implements Transport.
+func (m *MemoryTransport) Publish(topicName string, msg models.Message) (delivered, dropped int, err error) {
+	return m.registry.PublishMessage(topicName, msg)
+}
+
+// Subscribe implements Transport. It adds a subscriber to the topic before
+// replaying any requested history, so no message published in between can
+// fall into the gap, and drops any live message that duplicates one already
+// delivered as part of the history replay.
+func (m *MemoryTransport) Subscribe(ctx context.Context, topicName string, opts SubscribeOptions) (<-chan models.Message, error) {
+	t, exists := m.registry.GetTopic(topicName)
+	if !exists {
+		return nil, registry.ErrTopicNotFound
+	}
+
+	clientID := opts.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("transport-%p", &opts)
+	}
+
+	bufSize := opts.BufferSize
+	if bufSize <= 0 {
+		bufSize = 100
+	}
+
+	sub := subscriber.NewSubscriber(clientID, nil, bufSize)
+	t.AddSubscriber(sub)
+
+	out := make(chan models.Message, bufSize)
+
+	var lastFlushed uint64
+	if opts.From > 0 {
+		for _, msg := range t.GetSince(opts.From) {
+			out <- msg
+			if msg.Seq > lastFlushed {
+				lastFlushed = msg.Seq
+			}
+		}
+	}
+
+	go func(lastFlushed uint64) {
+		defer close(out)
+
+	forward:
+		for {
+			select {
+			case <-ctx.Done():
+				break forward
+			case serverMsg, ok := <-sub.Send:
+				if !ok {
+					break forward
+				}
+				if serverMsg.Message == nil {
+					continue
+				}
+				if serverMsg.Message.Seq != 0 && serverMsg.Message.Seq <= lastFlushed {
+					// Already delivered as part of the history flush above.
+					continue
+				}
+				select {
+				case out <- *serverMsg.Message:
+				case <-ctx.Done():
+					break forward
+				}
+			}
+		}
+
+		// Signal that we've stopped reading Send before asking the topic to
+		// remove (and close) this subscriber, mirroring the handoff used by
+		// the WebSocket subscribe path so Close doesn't deadlock waiting on
+		// Done.
+		close(sub.Done)
+		t.RemoveSubscriber(clientID)
+	}(lastFlushed)
+
+	return out, nil
+}
+
+// History implements Transport.
+func (m *MemoryTransport) History(topicName string, from uint64, limit int) ([]models.Message, error) {
+	t, exists := m.registry.GetTopic(topicName)
+	if !exists {
+		return nil, registry.ErrTopicNotFound
+	}
+
+	msgs := t.GetSince(from)
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+	return msgs, nil
+}
+
+// Close implements Transport by closing the underlying registry.
+func (m *MemoryTransport) Close() error {
+	m.registry.Close()
+	return nil
+}
@@ -0,0 +1,49 @@
+// Package transport decouples pub/sub semantics (publish, subscribe, and
+// history replay) from the storage backing a topic's message history. The
+// TopicManager talks to a Transport instead of the registry directly, so
+// operators can trade durability for speed by swapping implementations.
+package transport
+
+import (
+	"context"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+)
+
+// SubscribeOptions configures how a subscription is delivered.
+type SubscribeOptions struct {
+	// ClientID identifies the subscriber for logging and deduplication. If
+	// empty, an implementation generates one.
+	ClientID string
+
+	// From, if non-zero, asks the transport to deliver every message with
+	// Seq >= From from history before switching to live delivery.
+	From uint64
+
+	// BufferSize sets the channel buffer size for the returned delivery
+	// channel. Zero means an implementation-defined default.
+	BufferSize int
+}
+
+// Transport publishes and delivers messages for a topic, backed by
+// whatever storage an implementation chooses.
+type Transport interface {
+	// Publish appends a message to topic's history and delivers it to any
+	// live subscribers, returning how many received it and how many were
+	// dropped to overflow. MemoryTransport returns an error if the topic
+	// does not exist; BoltTransport creates the topic's bucket instead.
+	Publish(topicName string, m models.Message) (delivered, dropped int, err error)
+
+	// Subscribe returns a channel that receives every message published to
+	// topic from the time of the call onward (and, if opts.From is set,
+	// buffered history from that sequence number first). The channel is
+	// closed when ctx is done or the subscription is otherwise torn down.
+	Subscribe(ctx context.Context, topicName string, opts SubscribeOptions) (<-chan models.Message, error)
+
+	// History returns up to limit buffered messages for topic with
+	// Seq >= from, in sequence order. A limit of zero means no cap.
+	History(topicName string, from uint64, limit int) ([]models.Message, error)
+
+	// Close releases any resources held by the transport.
+	Close() error
+}
@@ -0,0 +1,170 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
+)
+
+func newMemoryTransport(t *testing.T, topicName string) *MemoryTransport {
+	t.Helper()
+
+	cfg := config.NewConfig()
+	reg := registry.NewRegistry(cfg, metrics.NewMetrics())
+	if err := reg.CreateTopic(topicName); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	return NewMemoryTransport(reg)
+}
+
+func TestMemoryTransport_PublishAndSubscribe(t *testing.T) {
+	tr := newMemoryTransport(t, "topic-a")
+	defer tr.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := tr.Subscribe(ctx, "topic-a", SubscribeOptions{ClientID: "sub-1"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if _, _, err := tr.Publish("topic-a", models.Message{ID: "1", Payload: json.RawMessage(`"hi"`)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.ID != "1" {
+			t.Errorf("Expected message ID 1, got %s", msg.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for published message")
+	}
+}
+
+func TestMemoryTransport_SubscribeUnknownTopic(t *testing.T) {
+	tr := newMemoryTransport(t, "topic-a")
+	defer tr.Close()
+
+	_, err := tr.Subscribe(context.Background(), "does-not-exist", SubscribeOptions{})
+	if err != registry.ErrTopicNotFound {
+		t.Errorf("Expected ErrTopicNotFound, got %v", err)
+	}
+}
+
+func TestMemoryTransport_History(t *testing.T) {
+	tr := newMemoryTransport(t, "topic-a")
+	defer tr.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := tr.Publish("topic-a", models.Message{ID: string(rune('a' + i))}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	msgs, err := tr.History("topic-a", 2, 0)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages from seq 2, got %d", len(msgs))
+	}
+}
+
+func newBoltTransport(t *testing.T) *BoltTransport {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "transport.db")
+	tr, err := NewBoltTransport(path)
+	if err != nil {
+		t.Fatalf("NewBoltTransport: %v", err)
+	}
+	t.Cleanup(func() { tr.Close() })
+	return tr
+}
+
+func TestBoltTransport_PublishAssignsSequence(t *testing.T) {
+	tr := newBoltTransport(t)
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := tr.Publish("topic-a", models.Message{ID: string(rune('a' + i))}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	msgs, err := tr.History("topic-a", 0, 0)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg.Seq != uint64(i+1) {
+			t.Errorf("Expected seq %d, got %d", i+1, msg.Seq)
+		}
+	}
+}
+
+func TestBoltTransport_HistorySurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transport.db")
+
+	tr, err := NewBoltTransport(path)
+	if err != nil {
+		t.Fatalf("NewBoltTransport: %v", err)
+	}
+	if _, _, err := tr.Publish("topic-a", models.Message{ID: "1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := tr.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewBoltTransport(path)
+	if err != nil {
+		t.Fatalf("NewBoltTransport (reopen): %v", err)
+	}
+	defer reopened.Close()
+
+	msgs, err := reopened.History("topic-a", 0, 0)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "1" {
+		t.Fatalf("Expected replayed message with ID 1, got %v", msgs)
+	}
+}
+
+func TestBoltTransport_SubscribeLiveDelivery(t *testing.T) {
+	tr := newBoltTransport(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := tr.Subscribe(ctx, "topic-a", SubscribeOptions{ClientID: "sub-1"})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if _, _, err := tr.Publish("topic-a", models.Message{ID: "1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.ID != "1" {
+			t.Errorf("Expected message ID 1, got %s", msg.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for published message")
+	}
+}
+
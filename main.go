@@ -1,225 +1,86 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"sync"
-	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/gorilla/mux"
-	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/transport"
+	"github.com/tanmay-xvx/inmem-pubsub/subscriberService"
+	subhttp "github.com/tanmay-xvx/inmem-pubsub/subscriberService/http"
 	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
 	tmhttp "github.com/tanmay-xvx/inmem-pubsub/topicManagerService/http"
 )
 
-// Message represents a pub/sub message
-type Message struct {
-	Topic     string      `json:"topic"`
-	Data      interface{} `json:"data"`
-	Timestamp time.Time   `json:"timestamp"`
-}
-
-// PubSubServer manages topics and subscribers
-type PubSubServer struct {
-	topics      map[string][]chan Message
-	subscribers map[string][]*websocket.Conn
-	mu          sync.RWMutex
-}
-
-// NewPubSubServer creates a new Pub/Sub server
-func NewPubSubServer() *PubSubServer {
-	return &PubSubServer{
-		topics:      make(map[string][]chan Message),
-		subscribers: make(map[string][]*websocket.Conn),
-	}
-}
-
-// Publish sends a message to all subscribers of a topic
-func (ps *PubSubServer) Publish(topic string, data interface{}) {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-
-	msg := Message{
-		Topic:     topic,
-		Data:      data,
-		Timestamp: time.Now(),
-	}
-
-	// Send to REST subscribers
-	if chans, exists := ps.topics[topic]; exists {
-		for _, ch := range chans {
-			select {
-			case ch <- msg:
-			default:
-				// Channel is full, skip
-			}
-		}
-	}
-
-	// Send to WebSocket subscribers
-	if conns, exists := ps.subscribers[topic]; exists {
-		for _, conn := range conns {
-			if err := conn.WriteJSON(msg); err != nil {
-				log.Printf("Error sending to WebSocket: %v", err)
-			}
-		}
-	}
-}
-
-// Subscribe creates a new subscription channel for a topic
-func (ps *PubSubServer) Subscribe(topic string) chan Message {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-
-	ch := make(chan Message, 100)
-	ps.topics[topic] = append(ps.topics[topic], ch)
-	return ch
-}
-
-// AddWebSocketSubscriber adds a WebSocket connection to a topic
-func (ps *PubSubServer) AddWebSocketSubscriber(topic string, conn *websocket.Conn) {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-
-	ps.subscribers[topic] = append(ps.subscribers[topic], conn)
-}
-
-// RemoveWebSocketSubscriber removes a WebSocket connection from a topic
-func (ps *PubSubServer) RemoveWebSocketSubscriber(topic string, conn *websocket.Conn) {
-	ps.mu.Lock()
-	defer ps.mu.Unlock()
-
-	if conns, exists := ps.subscribers[topic]; exists {
-		for i, c := range conns {
-			if c == conn {
-				ps.subscribers[topic] = append(conns[:i], conns[i+1:]...)
-				break
-			}
-		}
-	}
-}
-
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for demo
-	},
-}
-
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using defaults")
 	}
 
-	// Parse flags
-	port := flag.String("port", getEnv("PORT", "8080"), "HTTP server port")
-	host := flag.String("host", getEnv("HOST", "0.0.0.0"), "HTTP server host")
-	wsPath := flag.String("ws-path", getEnv("WS_PATH", "/ws"), "WebSocket endpoint path")
-	flag.Parse()
+	cfg := config.NewConfig()
 
-	// Create Pub/Sub server
-	ps := NewPubSubServer()
+	// Transport selection lives outside config.Config since it picks which
+	// transport.Transport backs the topic manager, not a setting either
+	// implementation reads itself.
+	transportKind := flag.String("transport", getEnv("TRANSPORT", "memory"), "Topic manager transport: memory or bolt")
+	boltPath := flag.String("bolt-path", getEnv("BOLT_PATH", "pubsub.db"), "Path to the bbolt database file when --transport=bolt")
+	cfg.ParseFlags()
 
-	// Create router
-	r := mux.NewRouter()
-
-	// REST API endpoints
-	r.HandleFunc("/publish/{topic}", func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		topic := vars["topic"]
-
-		var data interface{}
-		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-			http.Error(w, "Invalid JSON", http.StatusBadRequest)
-			return
-		}
-
-		ps.Publish(topic, data)
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Message published"))
-	}).Methods("POST")
-
-	r.HandleFunc("/subscribe/{topic}", func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		topic := vars["topic"]
-
-		// Set headers for Server-Sent Events
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-
-		// Subscribe to topic
-		ch := ps.Subscribe(topic)
-		defer close(ch)
+	r, tm, err := buildServer(cfg, *transportKind, *boltPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer tm.Close()
 
-		// Keep connection alive
-		notify := w.(http.CloseNotifier).CloseNotify()
-		go func() {
-			<-notify
-			ch <- Message{}
-		}()
+	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
+	log.Printf("Starting server on %s", addr)
+	log.Printf("WebSocket endpoint: ws://%s/ws", addr)
+	log.Fatal(http.ListenAndServe(addr, r))
+}
 
-		for {
-			select {
-			case msg := <-ch:
-				if msg.Topic == "" {
-					return // Connection closed
-				}
-				data, _ := json.Marshal(msg)
-				fmt.Fprintf(w, "data: %s\n\n", data)
-				w.(http.Flusher).Flush()
-			case <-time.After(30 * time.Second):
-				// Send keepalive
-				fmt.Fprintf(w, ": keepalive\n\n")
-				w.(http.Flusher).Flush()
-			}
-		}
-	}).Methods("GET")
+// buildServer wires a registry, topic manager, and subscriber service
+// behind a chi.Router exposing the real REST, WebSocket, and SSE API —
+// the same shape pubsubtest.NewFakeServer assembles for integration
+// tests — backed by the requested transport: "memory" for the in-memory
+// registry, or "bolt" for a bbolt-backed store at boltPath that survives a
+// restart.
+func buildServer(cfg *config.Config, transportKind, boltPath string) (chi.Router, *topicManagerService.TopicManagerServiceImpl, error) {
+	m := metrics.NewMetrics()
+	reg := registry.NewRegistry(cfg, m)
+	if err := reg.RestoreFromWAL(); err != nil {
+		return nil, nil, fmt.Errorf("restore topics from WAL: %w", err)
+	}
 
-	// WebSocket endpoint
-	r.HandleFunc(*wsPath, func(w http.ResponseWriter, r *http.Request) {
-		conn, err := upgrader.Upgrade(w, r, nil)
+	var t transport.Transport
+	switch transportKind {
+	case "bolt":
+		boltTransport, err := transport.NewBoltTransport(boltPath)
 		if err != nil {
-			log.Printf("WebSocket upgrade failed: %v", err)
-			return
+			return nil, nil, fmt.Errorf("initialize bolt transport at %q: %w", boltPath, err)
 		}
-		defer conn.Close()
-
-		// Handle WebSocket messages
-		for {
-			var msg Message
-			if err := conn.ReadJSON(&msg); err != nil {
-				log.Printf("WebSocket read error: %v", err)
-				break
-			}
+		t = boltTransport
+	case "memory", "":
+		t = transport.NewMemoryTransport(reg)
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q (want memory or bolt)", transportKind)
+	}
 
-			// Subscribe to topic if not already subscribed
-			ps.AddWebSocketSubscriber(msg.Topic, conn)
-			defer ps.RemoveWebSocketSubscriber(msg.Topic, conn)
+	tm := topicManagerService.NewTopicManagerService(reg, cfg, m, t)
+	subSvc := subscriberService.NewSubscriberService(reg, cfg, tm)
 
-			// Publish message
-			ps.Publish(msg.Topic, msg.Data)
-		}
-	})
+	r := chi.NewRouter()
+	tmhttp.NewHandler(tm).RegisterRoutes(r)
+	subhttp.RegisterSubscriberRoutes(r, subSvc)
 
-	// Health check
-	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
-
-	// Start server
-	addr := fmt.Sprintf("%s:%s", *host, *port)
-	log.Printf("Starting server on %s", addr)
-	log.Printf("WebSocket endpoint: ws://%s%s", addr, *wsPath)
-	log.Fatal(http.ListenAndServe(addr, r))
+	return r, tm, nil
 }
 
 // getEnv gets an environment variable or returns a default value
@@ -229,21 +90,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
-
-// RegisterTopicManagerRoutes registers all topic manager HTTP routes with the provided chi router.
-// This function mounts the following endpoints:
-//   - POST /topics - Create a new topic
-//   - DELETE /topics/{name} - Delete a topic by name
-//   - GET /topics - List all topics
-//   - GET /health - System health check
-//   - GET /stats - Topic statistics
-//
-// The function creates a new HTTP handler with the provided topic manager and
-// registers all routes with proper middleware.
-func RegisterTopicManagerRoutes(r chi.Router, mgr topicManagerService.TopicManager) {
-	// Create a new HTTP handler with the provided topic manager
-	handler := tmhttp.NewHandler(mgr)
-
-	// Register all routes with the handler
-	handler.RegisterRoutes(r)
-}
@@ -1,93 +1,109 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
-	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	tmhttp "github.com/tanmay-xvx/inmem-pubsub/topicManagerService/http"
 )
 
-// mockTopicManager is a mock implementation for testing
-type mockTopicManager struct{}
-
-func (m *mockTopicManager) CreateTopic(name string) error {
-	return nil
-}
-
-func (m *mockTopicManager) DeleteTopic(name string) error {
-	return nil
-}
-
-func (m *mockTopicManager) ListTopics() []topicManagerService.TopicInfo {
-	return []topicManagerService.TopicInfo{}
-}
-
-func (m *mockTopicManager) GetTopic(name string) (*topic.Topic, bool) {
-	return nil, false
-}
-
-func (m *mockTopicManager) Stats() map[string]topicManagerService.TopicStats {
-	return map[string]topicManagerService.TopicStats{}
-}
-
-// TestRegisterTopicManagerRoutes tests that the function can be called without errors
-func TestRegisterTopicManagerRoutes(t *testing.T) {
-	router := chi.NewRouter()
-	mockTM := &mockTopicManager{}
+// TestBuildServer_MountsRealHandlers verifies that the router buildServer
+// assembles is the real topicManagerService/http + subscriberService/http
+// API — the one the binary actually serves — not the legacy demo server.
+func TestBuildServer_MountsRealHandlers(t *testing.T) {
+	cfg := config.NewConfig()
+	r, tm, err := buildServer(cfg, "memory", "")
+	if err != nil {
+		t.Fatalf("buildServer: %v", err)
+	}
+	defer tm.Close()
 
-	// This should not panic or error
-	RegisterTopicManagerRoutes(router, mockTM)
+	server := httptest.NewServer(r)
+	defer server.Close()
 
-	// Test that at least one route is registered by trying to access health endpoint
-	req := httptest.NewRequest("GET", "/health", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("GET /health: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected /health to return 200, got %d", resp.StatusCode)
+	}
 
-	// The health endpoint should exist (even if it might return an error due to mock)
-	if w.Code == http.StatusNotFound {
-		t.Log("Health endpoint not found, but this is expected with mock implementation")
+	createBody, _ := json.Marshal(tmhttp.CreateTopicRequest{Name: "orders"})
+	resp, err = http.Post(server.URL+"/topics", "application/json", bytes.NewReader(createBody))
+	if err != nil {
+		t.Fatalf("POST /topics: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("expected /topics to return 201, got %d", resp.StatusCode)
 	}
-}
 
-func TestNewPubSubServer(t *testing.T) {
-	ps := NewPubSubServer()
-	if ps == nil {
-		t.Error("NewPubSubServer returned nil")
+	resp, err = http.Get(server.URL + "/topics")
+	if err != nil {
+		t.Fatalf("GET /topics: %v", err)
 	}
-	if ps.topics == nil {
-		t.Error("topics map is nil")
+	defer resp.Body.Close()
+	var listResp tmhttp.ListTopicsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		t.Fatalf("decode /topics: %v", err)
 	}
-	if ps.subscribers == nil {
-		t.Error("subscribers map is nil")
+	if len(listResp.Topics) != 1 || listResp.Topics[0].Name != "orders" {
+		t.Errorf("expected the created topic to be listed, got %+v", listResp.Topics)
 	}
 }
 
-func TestPubSubServer_Publish(t *testing.T) {
-	ps := NewPubSubServer()
-	topic := "test-topic"
-	data := "test-data"
+// TestBuildServer_PublishDeliversThroughTopicManager verifies that a
+// publish made over the real REST endpoint succeeds and reaches the topic
+// held by the topic manager that buildServer returned, which then retains
+// the message.
+func TestBuildServer_PublishDeliversThroughTopicManager(t *testing.T) {
+	cfg := config.NewConfig()
+	r, tm, err := buildServer(cfg, "memory", "")
+	if err != nil {
+		t.Fatalf("buildServer: %v", err)
+	}
+	defer tm.Close()
 
-	// Publish should not panic even with no subscribers
-	ps.Publish(topic, data)
-}
+	server := httptest.NewServer(r)
+	defer server.Close()
 
-func TestPubSubServer_Subscribe(t *testing.T) {
-	ps := NewPubSubServer()
-	topic := "test-topic"
+	createBody, _ := json.Marshal(tmhttp.CreateTopicRequest{Name: "orders"})
+	resp, err := http.Post(server.URL+"/topics", "application/json", bytes.NewReader(createBody))
+	if err != nil {
+		t.Fatalf("POST /topics: %v", err)
+	}
+	resp.Body.Close()
+
+	publishBody, _ := json.Marshal(tmhttp.PublishRequest{Payload: json.RawMessage(`{"amount":1}`)})
+	resp, err = http.Post(server.URL+"/topics/orders/publish", "application/json", bytes.NewReader(publishBody))
+	if err != nil {
+		t.Fatalf("POST /topics/orders/publish: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected publish to return 200, got %d", resp.StatusCode)
+	}
 
-	ch := ps.Subscribe(topic)
-	if ch == nil {
-		t.Error("Subscribe returned nil channel")
+	tp, ok := tm.GetTopic("orders")
+	if !ok {
+		t.Fatal("expected topic \"orders\" to exist")
+	}
+	if _, ok := tp.OldestSeq(); !ok {
+		t.Error("expected the published message to be retained by the topic")
 	}
+}
 
-	// Check if topic was added
-	ps.mu.RLock()
-	_, exists := ps.topics[topic]
-	ps.mu.RUnlock()
-	if !exists {
-		t.Error("Topic was not added to topics map")
+// TestBuildServer_UnknownTransport verifies buildServer rejects a transport
+// kind it doesn't recognize instead of silently falling back to memory.
+func TestBuildServer_UnknownTransport(t *testing.T) {
+	cfg := config.NewConfig()
+	if _, _, err := buildServer(cfg, "carrier-pigeon", ""); err == nil {
+		t.Error("expected an error for an unknown transport kind")
 	}
 }
@@ -0,0 +1,279 @@
+// Package pubsubtest provides an in-process fake inmem-pubsub server for
+// downstream integration tests, modeled on cloud.google.com/go/pubsub/pstest
+// and Beam's pubsubx test helpers. NewFakeServer wires a real Registry,
+// topic manager, and subscriber service behind an httptest.Server exposing
+// the same REST and WebSocket endpoints the real binary serves (see
+// main.go's --transport=memory wiring), so a caller can assert on delivery
+// semantics (fanout, overflow policies, disconnects) without spinning up
+// the real binary.
+package pubsubtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/transport"
+	"github.com/tanmay-xvx/inmem-pubsub/subscriberService"
+	subhttp "github.com/tanmay-xvx/inmem-pubsub/subscriberService/http"
+	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+	tmhttp "github.com/tanmay-xvx/inmem-pubsub/topicManagerService/http"
+)
+
+// FakeServer is an in-process inmem-pubsub server suitable for downstream
+// integration tests. Create one with NewFakeServer.
+type FakeServer struct {
+	// Server is the underlying httptest.Server; its URL is the base for
+	// the REST endpoints registered by topicManagerService/http.
+	Server *httptest.Server
+
+	// TopicMgr is the fully wired topic manager backing Server, for tests
+	// that want to inspect or drive topic state directly (e.g.
+	// TopicMgr.GetTopic) instead of going over HTTP.
+	TopicMgr *topicManagerService.TopicManagerServiceImpl
+
+	mu      sync.Mutex
+	clients []*Client
+}
+
+// NewFakeServer starts a fake server backed by an in-memory transport — the
+// same shape main.go assembles for --transport=memory — and registers
+// t.Cleanup to tear it down when the test finishes.
+func NewFakeServer(t *testing.T) *FakeServer {
+	t.Helper()
+
+	cfg := config.NewConfig()
+	m := metrics.NewMetrics()
+	reg := registry.NewRegistry(cfg, m)
+	tr := transport.NewMemoryTransport(reg)
+	tm := topicManagerService.NewTopicManagerService(reg, cfg, m, tr)
+	subSvc := subscriberService.NewSubscriberService(reg, cfg, tm)
+
+	r := chi.NewRouter()
+	tmhttp.NewHandler(tm).RegisterRoutes(r)
+	subhttp.RegisterSubscriberRoutes(r, subSvc)
+
+	fs := &FakeServer{
+		Server:   httptest.NewServer(r),
+		TopicMgr: tm,
+	}
+	t.Cleanup(fs.Cleanup)
+	return fs
+}
+
+// Cleanup closes every Client this server dialed, the httptest.Server, and
+// the underlying topic manager. NewFakeServer registers this with
+// t.Cleanup; tests only need to call it directly if they want the server
+// torn down before the test ends.
+func (s *FakeServer) Cleanup() {
+	s.mu.Lock()
+	clients := s.clients
+	s.clients = nil
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		c.Close()
+	}
+	s.Server.Close()
+	s.TopicMgr.Close()
+}
+
+// Publish posts payload, marshaled as JSON, to topic over the REST publish
+// endpoint, auto-creating the topic first if it doesn't already exist.
+// Returns the number of subscribers the message was delivered to and
+// dropped for, matching topic.Topic.Publish.
+func (s *FakeServer) Publish(topic string, payload interface{}) (delivered, dropped int, err error) {
+	if _, exists := s.TopicMgr.GetTopic(topic); !exists {
+		if err := s.TopicMgr.CreateTopic(topic); err != nil {
+			return 0, 0, fmt.Errorf("create topic %q: %w", topic, err)
+		}
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return 0, 0, fmt.Errorf("marshal payload: %w", err)
+	}
+	body, err := json.Marshal(tmhttp.PublishRequest{Payload: raw})
+	if err != nil {
+		return 0, 0, fmt.Errorf("marshal publish request: %w", err)
+	}
+
+	resp, err := http.Post(s.Server.URL+"/topics/"+topic+"/publish", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, fmt.Errorf("publish to %q: %w", topic, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("publish to %q: unexpected status %s", topic, resp.Status)
+	}
+
+	var publishResp tmhttp.PublishResponse
+	if err := json.NewDecoder(resp.Body).Decode(&publishResp); err != nil {
+		return 0, 0, fmt.Errorf("decode publish response: %w", err)
+	}
+	return publishResp.Delivered, publishResp.Dropped, nil
+}
+
+// DialOptions configures a subscription established via FakeServer.Dial. It
+// mirrors the fields of models.WSClientMsg a real subscribe message
+// accepts.
+type DialOptions struct {
+	// From replays every buffered message with Seq >= From before
+	// switching to live delivery. Zero means "start from live only",
+	// unless FromOldest is set.
+	From uint64
+
+	// FromOldest replays from the oldest sequence the topic still
+	// retains. It takes precedence over From.
+	FromOldest bool
+
+	// Filter, if set, is an expression in the internals/filter predicate
+	// language restricting delivery to matching messages.
+	Filter string
+
+	// Compression negotiates the payload encoding for this connection:
+	// "identity" (the default), "gzip", "deflate", or "br".
+	Compression string
+}
+
+// Dial opens a WebSocket connection to the server and subscribes it to
+// topic, auto-creating the topic first if it doesn't already exist.
+// Blocks until the subscribe is acknowledged or, if ctx has a deadline,
+// until that deadline passes; cancelling ctx without a deadline only
+// aborts the dial itself, not the wait for the ack. The returned
+// Client is tracked by the server and closed by Cleanup if the caller
+// doesn't close it first.
+func (s *FakeServer) Dial(ctx context.Context, topic string, opts DialOptions) (*Client, error) {
+	if _, exists := s.TopicMgr.GetTopic(topic); !exists {
+		if err := s.TopicMgr.CreateTopic(topic); err != nil {
+			return nil, fmt.Errorf("create topic %q: %w", topic, err)
+		}
+	}
+
+	wsURL := "ws" + strings.TrimPrefix(s.Server.URL, "http") + "/ws"
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dial %q: %w", wsURL, err)
+	}
+
+	requestID := fmt.Sprintf("pubsubtest-%d", time.Now().UnixNano())
+	sub := models.WSClientMsg{
+		Type:        "subscribe",
+		Topic:       topic,
+		RequestID:   requestID,
+		From:        opts.From,
+		FromOldest:  opts.FromOldest,
+		Filter:      opts.Filter,
+		Compression: opts.Compression,
+	}
+	if err := conn.WriteJSON(sub); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send subscribe for %q: %w", topic, err)
+	}
+
+	c := &Client{
+		conn:     conn,
+		Topic:    topic,
+		Messages: make(chan models.ServerMsg, 64),
+		done:     make(chan struct{}),
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	for {
+		var msg models.ServerMsg
+		if err := conn.ReadJSON(&msg); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("waiting for subscribe ack on %q: %w", topic, err)
+		}
+		if msg.RequestID != requestID {
+			// A message delivered before our subscribe was acked (e.g. the
+			// "connected" welcome frame) — hand it to the caller in order.
+			c.Messages <- msg
+			continue
+		}
+		if msg.Type == "error" {
+			conn.Close()
+			errMsg := "subscribe failed"
+			if msg.Error != nil {
+				errMsg = msg.Error.Message
+			}
+			return nil, fmt.Errorf("subscribe to %q: %s", topic, errMsg)
+		}
+		break
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go c.readLoop()
+
+	s.mu.Lock()
+	s.clients = append(s.clients, c)
+	s.mu.Unlock()
+
+	return c, nil
+}
+
+// Client is a lightweight WebSocket subscriber returned by FakeServer.Dial.
+// It decodes every inbound frame and yields it as a models.ServerMsg on
+// Messages, so a test can assert on delivery semantics (fanout, dropped
+// messages under an overflow policy, replayed history, ...) without
+// decoding the wire format itself.
+type Client struct {
+	conn  *websocket.Conn
+	Topic string
+
+	// Messages receives every ServerMsg this connection's subscription
+	// gets, in order, including the initial "connected" welcome frame
+	// received before the subscribe ack (see FakeServer.Dial). Closed when
+	// the connection is closed or a read fails.
+	Messages chan models.ServerMsg
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// readLoop forwards every frame the connection receives to Messages until
+// a read fails, then closes Messages.
+func (c *Client) readLoop() {
+	defer close(c.Messages)
+	defer c.conn.Close()
+
+	for {
+		var msg models.ServerMsg
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		select {
+		case c.Messages <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Close closes the underlying WebSocket connection. Safe to call more than
+// once.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		err = c.conn.Close()
+	})
+	return err
+}
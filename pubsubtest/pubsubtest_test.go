@@ -0,0 +1,101 @@
+package pubsubtest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func waitForMessage(t *testing.T, c *Client, timeout time.Duration) map[string]interface{} {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg, ok := <-c.Messages:
+			if !ok {
+				t.Fatal("Messages closed before the expected delivery arrived")
+			}
+			if msg.Type != "message" {
+				continue
+			}
+			var payload map[string]interface{}
+			if err := json.Unmarshal(msg.Message.Payload, &payload); err != nil {
+				t.Fatalf("unmarshal payload: %v", err)
+			}
+			return payload
+		case <-deadline:
+			t.Fatal("timed out waiting for a delivered message")
+			return nil
+		}
+	}
+}
+
+func TestFakeServer_FanoutToMultipleSubscribers(t *testing.T) {
+	s := NewFakeServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	a, err := s.Dial(ctx, "orders", DialOptions{})
+	if err != nil {
+		t.Fatalf("Dial a: %v", err)
+	}
+	b, err := s.Dial(ctx, "orders", DialOptions{})
+	if err != nil {
+		t.Fatalf("Dial b: %v", err)
+	}
+
+	delivered, dropped, err := s.Publish("orders", map[string]int{"n": 1})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if delivered != 2 || dropped != 0 {
+		t.Fatalf("expected 2 delivered, 0 dropped, got %d delivered, %d dropped", delivered, dropped)
+	}
+
+	for _, c := range []*Client{a, b} {
+		payload := waitForMessage(t, c, time.Second)
+		if payload["n"] != float64(1) {
+			t.Errorf("expected payload n=1, got %v", payload)
+		}
+	}
+}
+
+func TestFakeServer_PublishAutoCreatesTopic(t *testing.T) {
+	s := NewFakeServer(t)
+
+	if _, exists := s.TopicMgr.GetTopic("fresh"); exists {
+		t.Fatal("expected topic not to exist before the first publish")
+	}
+
+	if _, _, err := s.Publish("fresh", "hello"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if _, exists := s.TopicMgr.GetTopic("fresh"); !exists {
+		t.Error("expected Publish to auto-create the topic")
+	}
+}
+
+func TestFakeServer_DialReplaysFromOldest(t *testing.T) {
+	s := NewFakeServer(t)
+	if err := s.TopicMgr.CreateTopic("history"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+	if _, _, err := s.Publish("history", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c, err := s.Dial(ctx, "history", DialOptions{FromOldest: true})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+
+	payload := waitForMessage(t, c, time.Second)
+	if payload["n"] != float64(1) {
+		t.Errorf("expected the replayed payload n=1, got %v", payload)
+	}
+}
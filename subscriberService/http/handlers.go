@@ -10,6 +10,10 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/codec"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/compress"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/filter"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
 	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
@@ -23,40 +27,172 @@ const (
 	MsgTypePing        = "ping"
 	MsgTypePong        = "pong"
 	MsgTypeAck         = "ack"
+	MsgTypeNack        = "nack"
+	MsgTypeModAck      = "modack"
+	MsgTypeReplay      = "replay"
 	MsgTypeError       = "error"
 )
 
+const (
+	// pongWait is how long a connection may stay silent (no pong, no other
+	// read) before the read deadline fires and the connection is torn down.
+	pongWait = 60 * time.Second
+
+	// pingPeriod is how often the writer goroutine sends a WebSocket-level
+	// ping control frame to keep the connection alive and detect dead
+	// peers faster than TCP timeouts would. It must be shorter than
+	// pongWait.
+	pingPeriod = (pongWait * 9) / 10
+
+	// writeWait bounds how long a single WriteMessage call (ping or data
+	// frame) may block before it is treated as a failed write.
+	writeWait = 10 * time.Second
+
+	// readBufferSize and writeBufferSize size the Upgrader's I/O buffers.
+	readBufferSize  = 4096
+	writeBufferSize = 4096
+)
+
+// Subprotocols a client may request via the Sec-WebSocket-Protocol
+// handshake header to negotiate a non-default wire Codec (see
+// codecForSubprotocol). A client that doesn't ask for one of these, or
+// asks for none at all, gets the default JSON codec.
+const (
+	subprotocolMsgpack     = "pubsub.msgpack"
+	subprotocolMsgpackGzip = "pubsub.msgpack+gzip"
+	subprotocolMsgpackBr   = "pubsub.msgpack+br"
+)
+
+var supportedSubprotocols = []string{subprotocolMsgpack, subprotocolMsgpackGzip, subprotocolMsgpackBr}
+
+// codecForSubprotocol maps a negotiated Sec-WebSocket-Protocol value to the
+// Codec a connection should use, or nil for the default JSON codec (either
+// because proto is empty or because the handshake didn't agree on one of
+// supportedSubprotocols).
+func codecForSubprotocol(proto string, minCompressSize int) codec.Codec {
+	switch proto {
+	case subprotocolMsgpack:
+		return codec.Msgpack
+	case subprotocolMsgpackGzip:
+		return codec.CompressedCodec{Inner: codec.Msgpack, Enc: compress.EncodingGzip, MinSize: minCompressSize}
+	case subprotocolMsgpackBr:
+		return codec.CompressedCodec{Inner: codec.Msgpack, Enc: compress.EncodingBrotli, MinSize: minCompressSize}
+	default:
+		return nil
+	}
+}
+
 // WebSocketHandler manages WebSocket connections and handles client messages.
 type WebSocketHandler struct {
-	topicManager topicManagerService.TopicManager
-	upgrader     websocket.Upgrader
+	topicManager    topicManagerService.TopicManager
+	upgrader        websocket.Upgrader
+	metrics         *metrics.Metrics
+	minCompressSize int
+
+	// pongWait, pingPeriod, and writeWait parameterize keepalive timing;
+	// they default to the package constants of the same name but can be
+	// tightened by NewWebSocketHandlerWithConfig, e.g. so tests don't wait
+	// a full minute for a dead connection to be reaped.
+	pongWait   time.Duration
+	pingPeriod time.Duration
+	writeWait  time.Duration
+
+	// defaultCompression is the payload encoding a connection negotiates
+	// when it doesn't request one itself (see WebSocketConfig and
+	// HandleWebSocket's ?encoding= query param handling).
+	defaultCompression compress.Encoding
 
 	// Connection management
 	connsMu sync.RWMutex
 	conns   map[*websocket.Conn]*connectionInfo
 }
 
+// WebSocketConfig parameterizes a WebSocketHandler's keepalive timing and
+// default compression. A zero value for PongWait, PingPeriod, or WriteWait
+// falls back to the package default of the same name; an empty
+// DefaultCompression falls back to compress.EncodingIdentity.
+type WebSocketConfig struct {
+	PongWait   time.Duration
+	PingPeriod time.Duration
+	WriteWait  time.Duration
+
+	// DefaultCompression is the payload encoding applied to a connection
+	// that negotiates no Sec-WebSocket-Protocol codec and sends no
+	// non-identity ?encoding= query param. A subscribe message's
+	// Compression field replaces it only while it is identity.
+	DefaultCompression compress.Encoding
+}
+
 // connectionInfo tracks information about a WebSocket connection
 type connectionInfo struct {
 	clientID    string
 	subscribers map[string]*subscriber.Subscriber // topic -> subscriber
 	writeChan   chan models.ServerMsg             // unified write channel for all messages
 	mu          sync.RWMutex
+
+	// encoding is the payload compression negotiated on first subscribe;
+	// it applies to every message written to this connection thereafter.
+	encoding compress.Encoding
+
+	// codec is the wire format negotiated on handshake via
+	// Sec-WebSocket-Protocol (see codecForSubprotocol). nil means the
+	// default JSON codec, which writeServerMsg falls back to.
+	codec codec.Codec
+
+	// dropOnce ensures a slow connection is only torn down once, even if
+	// multiple sends find writeChan full before the close takes effect.
+	dropOnce sync.Once
 }
 
-// NewWebSocketHandler creates a new WebSocket handler with the specified topic manager.
+// NewWebSocketHandler creates a new WebSocket handler with the specified
+// topic manager and the default keepalive timing (see WebSocketConfig).
 func NewWebSocketHandler(topicManager topicManagerService.TopicManager) *WebSocketHandler {
+	return NewWebSocketHandlerWithConfig(topicManager, WebSocketConfig{})
+}
+
+// NewWebSocketHandlerWithConfig creates a new WebSocket handler with the
+// specified topic manager and keepalive timing, falling back to pongWait,
+// pingPeriod, and writeWait for any zero field in cfg.
+func NewWebSocketHandlerWithConfig(topicManager topicManagerService.TopicManager, cfg WebSocketConfig) *WebSocketHandler {
+	if cfg.PongWait <= 0 {
+		cfg.PongWait = pongWait
+	}
+	if cfg.PingPeriod <= 0 {
+		cfg.PingPeriod = pingPeriod
+	}
+	if cfg.WriteWait <= 0 {
+		cfg.WriteWait = writeWait
+	}
+	if cfg.DefaultCompression == "" {
+		cfg.DefaultCompression = compress.EncodingIdentity
+	}
+
 	return &WebSocketHandler{
 		topicManager: topicManager,
 		upgrader: websocket.Upgrader{
+			ReadBufferSize:  readBufferSize,
+			WriteBufferSize: writeBufferSize,
+			Subprotocols:    supportedSubprotocols,
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for now
 			},
 		},
-		conns: make(map[*websocket.Conn]*connectionInfo),
+		conns:              make(map[*websocket.Conn]*connectionInfo),
+		metrics:            metrics.NewMetrics(),
+		minCompressSize:    topicManagerService.DefaultMinCompressSize,
+		pongWait:           cfg.PongWait,
+		pingPeriod:         cfg.PingPeriod,
+		writeWait:          cfg.WriteWait,
+		defaultCompression: cfg.DefaultCompression,
 	}
 }
 
+// CompressionStats returns a snapshot of bytes-in/bytes-out per negotiated
+// payload encoding across every connection served by this handler.
+func (h *WebSocketHandler) CompressionStats() map[string]metrics.CompressionStats {
+	return h.metrics.GetCompressionStats()
+}
+
 // HandleWebSocket upgrades the HTTP request to WebSocket and handles the connection.
 func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Upgrade to WebSocket
@@ -72,10 +208,33 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		clientID:    generateClientID(),
 		subscribers: make(map[string]*subscriber.Subscriber),
 		writeChan:   make(chan models.ServerMsg, 100),
+		codec:       codecForSubprotocol(conn.Subprotocol(), h.minCompressSize),
+	}
+
+	// Negotiate a default payload encoding for connections that don't
+	// otherwise set one via Sec-WebSocket-Protocol: an explicit
+	// ?encoding= query param wins, falling back to h.defaultCompression. A
+	// subscribe message's Compression field (see handleSubscribe) only
+	// applies if still unset by the time it arrives.
+	if connInfo.codec == nil {
+		connInfo.encoding = h.defaultCompression
+		if q := r.URL.Query().Get("encoding"); q != "" {
+			if enc := compress.Negotiate(q); enc != compress.EncodingIdentity {
+				connInfo.encoding = enc
+			}
+		}
 	}
 
+	// Prime the read deadline and refresh it on every pong, so a peer that
+	// stops responding is detected within pongWait instead of hanging
+	// around forever.
+	conn.SetReadDeadline(time.Now().Add(h.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.pongWait))
+	})
+
 	// Start single writer goroutine for this connection
-	go h.unifiedWriter(conn, connInfo.writeChan)
+	go h.unifiedWriter(conn, connInfo)
 
 	// Register connection
 	h.connsMu.Lock()
@@ -101,15 +260,112 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 
 // unifiedWriter is the single goroutine responsible for writing all messages to a WebSocket connection.
 // This prevents concurrent write race conditions by ensuring only one writer.
-func (h *WebSocketHandler) unifiedWriter(conn *websocket.Conn, writeChan <-chan models.ServerMsg) {
-	for msg := range writeChan {
-		if err := conn.WriteJSON(msg); err != nil {
-			log.Printf("Failed to write message to WebSocket: %v", err)
-			break
+// It also owns the ping ticker: every pingPeriod it sends a WebSocket-level
+// ping so idle-but-alive connections are distinguished from dead ones well
+// before pongWait would otherwise time out the reader.
+func (h *WebSocketHandler) unifiedWriter(conn *websocket.Conn, connInfo *connectionInfo) {
+	ticker := time.NewTicker(h.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg, ok := <-connInfo.writeChan:
+			if !ok {
+				return
+			}
+			if err := h.writeServerMsg(conn, connInfo, msg); err != nil {
+				log.Printf("Failed to write message to WebSocket: %v", err)
+				return
+			}
+
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(h.writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				log.Printf("Failed to ping client %s: %v", connInfo.clientID, err)
+				return
+			}
 		}
 	}
 }
 
+// writeServerMsg marshals msg with connInfo's negotiated Codec, if any, and
+// writes it as a binary frame prefixed with the codec's content-type byte.
+// Without a Codec it marshals msg to JSON and, if connInfo negotiated a
+// compression encoding and the payload is at least minCompressSize,
+// compresses it and writes a binary frame prefixed with a one-byte
+// encoding tag; otherwise it writes the JSON as a text frame, matching the
+// previous WriteJSON behavior for backward compatibility.
+func (h *WebSocketHandler) writeServerMsg(conn *websocket.Conn, connInfo *connectionInfo, msg models.ServerMsg) error {
+	connInfo.mu.RLock()
+	c := connInfo.codec
+	connInfo.mu.RUnlock()
+
+	if c != nil {
+		payload, contentType, err := c.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("marshal server message: %w", err)
+		}
+		conn.SetWriteDeadline(time.Now().Add(h.writeWait))
+		h.metrics.IncCompressionBytes(msg.Topic, string(compress.EncodingIdentity), len(payload), len(payload))
+		return conn.WriteMessage(websocket.BinaryMessage, append([]byte{contentType}, payload...))
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal server message: %w", err)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(h.writeWait))
+
+	connInfo.mu.RLock()
+	enc := connInfo.encoding
+	connInfo.mu.RUnlock()
+
+	if enc == "" || enc == compress.EncodingIdentity || len(payload) < h.minCompressSize {
+		h.metrics.IncCompressionBytes(msg.Topic, string(compress.EncodingIdentity), len(payload), len(payload))
+		return conn.WriteMessage(websocket.TextMessage, payload)
+	}
+
+	compressed, err := compress.Compress(enc, payload)
+	if err != nil {
+		log.Printf("Client %s: compression failed, sending uncompressed: %v", connInfo.clientID, err)
+		h.metrics.IncCompressionBytes(msg.Topic, string(compress.EncodingIdentity), len(payload), len(payload))
+		return conn.WriteMessage(websocket.TextMessage, payload)
+	}
+
+	h.metrics.IncCompressionBytes(msg.Topic, string(enc), len(payload), len(compressed))
+	frame := append([]byte{encodingTag(enc)}, compressed...)
+	return conn.WriteMessage(websocket.BinaryMessage, frame)
+}
+
+// dropSlowConnection tears down a connection whose writeChan is full rather
+// than letting a single slow subscriber block delivery to everyone else.
+// It is safe to call repeatedly for the same connection; only the first
+// call takes effect.
+func (h *WebSocketHandler) dropSlowConnection(conn *websocket.Conn, connInfo *connectionInfo) {
+	connInfo.dropOnce.Do(func() {
+		h.metrics.IncDroppedSubscribers()
+		log.Printf("Client %s: write channel full, dropping slow connection", connInfo.clientID)
+		conn.Close()
+	})
+}
+
+// encodingTag maps a negotiated encoding to the one-byte tag prefixed to
+// compressed binary frames so a compression-aware client can pick the
+// right decompressor.
+func encodingTag(enc compress.Encoding) byte {
+	switch enc {
+	case compress.EncodingGzip:
+		return 1
+	case compress.EncodingDeflate:
+		return 2
+	case compress.EncodingBrotli:
+		return 3
+	default:
+		return 0
+	}
+}
+
 // handleMessages reads and processes incoming WebSocket messages.
 func (h *WebSocketHandler) handleMessages(conn *websocket.Conn, connInfo *connectionInfo) {
 	for {
@@ -139,6 +395,14 @@ func (h *WebSocketHandler) handleMessages(conn *websocket.Conn, connInfo *connec
 			h.handlePublish(conn, connInfo, &clientMsg)
 		case MsgTypePing:
 			h.handlePing(conn, &clientMsg)
+		case MsgTypeAck:
+			h.handleAck(connInfo, &clientMsg)
+		case MsgTypeNack:
+			h.handleNack(connInfo, &clientMsg)
+		case MsgTypeModAck:
+			h.handleModAck(connInfo, &clientMsg)
+		case MsgTypeReplay:
+			h.handleReplay(conn, connInfo, &clientMsg)
 		default:
 			h.sendError(conn, "UNKNOWN_TYPE", fmt.Sprintf("Unknown message type: %s", clientMsg.Type))
 		}
@@ -168,25 +432,108 @@ func (h *WebSocketHandler) handleSubscribe(conn *websocket.Conn, connInfo *conne
 	}
 	connInfo.mu.RUnlock()
 
+	// Negotiate payload compression from the first subscribe message; it
+	// then applies to every message delivered on this connection.
+	if msg.Compression != "" {
+		connInfo.mu.Lock()
+		if connInfo.encoding == "" || connInfo.encoding == compress.EncodingIdentity {
+			connInfo.encoding = compress.Encoding(msg.Compression)
+		}
+		connInfo.mu.Unlock()
+	}
+
+	// FromOldest resolves to the oldest sequence the topic can still
+	// replay, on disk or in memory, so the client doesn't need to know
+	// that number up front. It takes precedence over From.
+	if msg.FromOldest {
+		if first, ok := topic.FirstSeq(); ok {
+			msg.From = first
+		}
+	}
+
+	// If the client asked to resume from a sequence index, make sure we can
+	// actually satisfy it before creating any subscriber state. This checks
+	// against FirstSeq, not just the in-memory ring, so a request that the
+	// WAL can still satisfy isn't rejected.
+	if msg.From > 0 {
+		if first, ok := topic.FirstSeq(); ok && msg.From < first {
+			h.sendError(conn, "SEQ_TOO_OLD", fmt.Sprintf(
+				"requested from=%d is older than the oldest retained sequence %d for topic '%s'; messages were missed",
+				msg.From, first, msg.Topic))
+			return
+		}
+	}
+
+	// Parse the filter expression, if any, before creating any subscriber
+	// state so a malformed expression fails the subscribe cleanly.
+	var pred *filter.Predicate
+	if msg.Filter != "" {
+		var err error
+		pred, err = filter.Compile(msg.Filter)
+		if err != nil {
+			h.sendError(conn, "INVALID_FILTER", fmt.Sprintf("Invalid filter expression: %v", err))
+			return
+		}
+	}
+
 	// Create subscriber and forward its messages to unified write channel
 	sub := subscriber.NewSubscriber(connInfo.clientID, nil, 100) // No direct WebSocket connection
+	sub.SetFilter(pred)
+	connInfo.mu.RLock()
+	sub.SetCodec(connInfo.codec)
+	connInfo.mu.RUnlock()
+
+	// Add the subscriber to the topic before reading any buffered history so
+	// no message published from this point on can fall into the gap between
+	// the history snapshot and going live.
+	topic.AddSubscriber(sub)
+
+	// Flush buffered history for resuming clients, then hand off to live
+	// delivery. Because the subscriber was already added above, a message
+	// published in between may appear in both the snapshot and the live
+	// queue; lastFlushed lets the live forwarder drop that duplicate.
+	var lastFlushed uint64
+	if msg.From > 0 {
+		history, err := topic.ReplayFrom(msg.From)
+		if err != nil {
+			h.sendError(conn, "REPLAY_FAILED", fmt.Sprintf("Replay from %d failed: %v", msg.From, err))
+		}
+		for _, m := range history {
+			m := m
+			serverMsg := models.ServerMsg{
+				Type:    "message",
+				Topic:   msg.Topic,
+				Message: &m,
+				Ts:      time.Now(),
+			}
+			select {
+			case connInfo.writeChan <- serverMsg:
+			default:
+				h.dropSlowConnection(conn, connInfo)
+			}
+			if m.Seq > lastFlushed {
+				lastFlushed = m.Seq
+			}
+		}
+	}
 
 	// Start a goroutine that forwards messages from subscriber to unified write channel
 	// and properly closes the Done channel when finished
-	go func() {
+	go func(lastFlushed uint64) {
 		defer close(sub.Done) // Ensure Done channel is closed when this goroutine exits
 		for msg := range sub.Send {
+			if msg.Message != nil && msg.Message.Seq != 0 && msg.Message.Seq <= lastFlushed {
+				// Already delivered as part of the history flush above.
+				continue
+			}
 			select {
 			case connInfo.writeChan <- msg:
 				// Message forwarded successfully
 			default:
-				log.Printf("Warning: write channel full for client %s", connInfo.clientID)
+				h.dropSlowConnection(conn, connInfo)
 			}
 		}
-	}()
-
-	// Add subscriber to topic
-	topic.AddSubscriber(sub)
+	}(lastFlushed)
 
 	// Track subscriber
 	connInfo.mu.Lock()
@@ -233,6 +580,56 @@ func (h *WebSocketHandler) handleUnsubscribe(conn *websocket.Conn, connInfo *con
 	log.Printf("Client %s unsubscribed from topic '%s'", connInfo.clientID, msg.Topic)
 }
 
+// handleReplay handles one-shot history requests that don't start a live
+// subscription: from_seq (msg.From) replays every retained message with
+// Seq >= From, reaching past the in-memory ring into the topic's WAL on
+// disk when it has one, so a client can catch up after missing more than
+// the ring buffer still holds. When From is zero it falls back to msg.LastN
+// for compatibility with clients that only know the ring buffer's recent
+// history.
+func (h *WebSocketHandler) handleReplay(conn *websocket.Conn, connInfo *connectionInfo, msg *models.WSClientMsg) {
+	if msg.Topic == "" {
+		h.sendError(conn, "MISSING_TOPIC", "Topic is required for replay")
+		return
+	}
+
+	topic, exists := h.topicManager.GetTopic(msg.Topic)
+	if !exists {
+		h.sendError(conn, "TOPIC_NOT_FOUND", fmt.Sprintf("Topic '%s' not found", msg.Topic))
+		return
+	}
+
+	var messages []models.Message
+	if msg.From > 0 {
+		replayed, err := topic.ReplayFrom(msg.From)
+		if err != nil {
+			h.sendError(conn, "REPLAY_FAILED", fmt.Sprintf("Replay from %d failed: %v", msg.From, err))
+			return
+		}
+		messages = replayed
+	} else {
+		messages = topic.GetLastN(msg.LastN)
+	}
+
+	for _, m := range messages {
+		m := m
+		serverMsg := models.ServerMsg{
+			Type:    "message",
+			Topic:   msg.Topic,
+			Message: &m,
+			Ts:      time.Now(),
+		}
+		select {
+		case connInfo.writeChan <- serverMsg:
+		default:
+			h.dropSlowConnection(conn, connInfo)
+			return
+		}
+	}
+
+	h.sendAck(conn, msg.RequestID, fmt.Sprintf("Replayed %d messages from topic '%s'", len(messages), msg.Topic))
+}
+
 // handlePublish handles publish requests.
 func (h *WebSocketHandler) handlePublish(conn *websocket.Conn, connInfo *connectionInfo, msg *models.WSClientMsg) {
 	if msg.Topic == "" {
@@ -251,14 +648,19 @@ func (h *WebSocketHandler) handlePublish(conn *websocket.Conn, connInfo *connect
 	}
 
 	// Check if topic exists
-	topic, exists := h.topicManager.GetTopic(msg.Topic)
-	if !exists {
+	if _, exists := h.topicManager.GetTopic(msg.Topic); !exists {
 		h.sendError(conn, "TOPIC_NOT_FOUND", fmt.Sprintf("Topic '%s' not found", msg.Topic))
 		return
 	}
 
-	// Publish message
-	delivered, dropped := topic.Publish(*msg.Message, "DROP_OLDEST", 100)
+	// Publish through the topic manager, not the topic directly, so the
+	// configured overflow policy, pattern-subscriber fan-out, and cluster
+	// forwarding all apply the same way they do for every other publish path.
+	delivered, dropped, err := h.topicManager.Publish(msg.Topic, *msg.Message)
+	if err != nil {
+		h.sendError(conn, "PUBLISH_FAILED", fmt.Sprintf("Failed to publish to topic '%s': %v", msg.Topic, err))
+		return
+	}
 
 	// Send acknowledgment
 	h.sendAck(conn, msg.RequestID, fmt.Sprintf("Message published: %d delivered, %d dropped", delivered, dropped))
@@ -287,7 +689,77 @@ func (h *WebSocketHandler) handlePing(conn *websocket.Conn, msg *models.WSClient
 	case connInfo.writeChan <- pongMsg:
 		// Message sent successfully
 	default:
-		log.Printf("Warning: write channel full for client %s", connInfo.clientID)
+		h.dropSlowConnection(conn, connInfo)
+	}
+}
+
+// handleAck acknowledges an in-flight message delivered under an AckMode
+// subscription (see topic.AckModePolicy), so it isn't redelivered. The
+// client's message ID may belong to any of this connection's subscriptions,
+// so every one is tried until it's found.
+func (h *WebSocketHandler) handleAck(connInfo *connectionInfo, msg *models.WSClientMsg) {
+	if msg.ID == "" {
+		return
+	}
+
+	connInfo.mu.RLock()
+	subs := make([]*subscriber.Subscriber, 0, len(connInfo.subscribers))
+	for _, sub := range connInfo.subscribers {
+		subs = append(subs, sub)
+	}
+	connInfo.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.Ack(msg.ID) {
+			return
+		}
+	}
+}
+
+// handleNack forces immediate redelivery of an in-flight AckMode message,
+// instead of making the client wait out the rest of its ack deadline. The
+// client's message ID may belong to any of this connection's subscriptions,
+// so every one is tried until it's found.
+func (h *WebSocketHandler) handleNack(connInfo *connectionInfo, msg *models.WSClientMsg) {
+	if msg.ID == "" {
+		return
+	}
+
+	connInfo.mu.RLock()
+	subs := make([]*subscriber.Subscriber, 0, len(connInfo.subscribers))
+	for _, sub := range connInfo.subscribers {
+		subs = append(subs, sub)
+	}
+	connInfo.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.Nack(msg.ID) {
+			return
+		}
+	}
+}
+
+// handleModAck extends an in-flight AckMode message's ack deadline by
+// msg.AckDeadline, so a client still processing it can avoid a redelivery.
+// The client's message ID may belong to any of this connection's
+// subscriptions, so every one is tried until it's found.
+func (h *WebSocketHandler) handleModAck(connInfo *connectionInfo, msg *models.WSClientMsg) {
+	if msg.ID == "" || msg.AckDeadline <= 0 {
+		return
+	}
+
+	connInfo.mu.RLock()
+	subs := make([]*subscriber.Subscriber, 0, len(connInfo.subscribers))
+	for _, sub := range connInfo.subscribers {
+		subs = append(subs, sub)
+	}
+	connInfo.mu.RUnlock()
+
+	newDeadline := time.Now().Add(msg.AckDeadline)
+	for _, sub := range subs {
+		if sub.ModAck(msg.ID, newDeadline) {
+			return
+		}
 	}
 }
 
@@ -315,7 +787,7 @@ func (h *WebSocketHandler) sendAck(conn *websocket.Conn, requestID, message stri
 	case connInfo.writeChan <- ackMsg:
 		// Message sent successfully
 	default:
-		log.Printf("Warning: write channel full for client %s", connInfo.clientID)
+		h.dropSlowConnection(conn, connInfo)
 	}
 }
 
@@ -342,7 +814,7 @@ func (h *WebSocketHandler) sendError(conn *websocket.Conn, code, message string)
 	case connInfo.writeChan <- errorMsg:
 		// Message sent successfully
 	default:
-		log.Printf("Warning: write channel full for client %s", connInfo.clientID)
+		h.dropSlowConnection(conn, connInfo)
 	}
 }
 
@@ -0,0 +1,248 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/ringbuffer"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// mockTopicManager is a mock implementation for testing, mirroring the one
+// in topicManagerService/http/http_test.go.
+type mockTopicManager struct {
+	topics map[string]*topic.Topic
+}
+
+func (m *mockTopicManager) CreateTopic(name string) error {
+	return m.CreateTopicWithTTL(name, 0)
+}
+
+func (m *mockTopicManager) CreateTopicWithOptions(name string, opts topicManagerService.TopicOptions) error {
+	return m.CreateTopicWithTTL(name, opts.TTL)
+}
+
+func (m *mockTopicManager) CreateTopicWithTTL(name string, ttl time.Duration) error {
+	if m.topics == nil {
+		m.topics = make(map[string]*topic.Topic)
+	}
+	m.topics[name] = topic.NewTopic(name, 10)
+	return nil
+}
+
+func (m *mockTopicManager) DeleteTopic(name string) error {
+	delete(m.topics, name)
+	return nil
+}
+
+func (m *mockTopicManager) ListTopics() []topicManagerService.TopicInfo {
+	topics := make([]topicManagerService.TopicInfo, 0, len(m.topics))
+	for name := range m.topics {
+		topics = append(topics, topicManagerService.TopicInfo{Name: name})
+	}
+	return topics
+}
+
+func (m *mockTopicManager) GetTopic(name string) (*topic.Topic, bool) {
+	t, ok := m.topics[name]
+	return t, ok
+}
+
+func (m *mockTopicManager) Publish(name string, msg models.Message) (delivered, dropped int, err error) {
+	t, ok := m.topics[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("topic %q not found", name)
+	}
+	delivered, dropped = t.Publish(msg, topic.DropOldest())
+	return delivered, dropped, nil
+}
+
+func (m *mockTopicManager) Stats() map[string]topicManagerService.TopicStats {
+	return map[string]topicManagerService.TopicStats{}
+}
+
+func (m *mockTopicManager) MetricsRegistry() metrics.Registry {
+	return metrics.Noop()
+}
+
+// TestWebSocketHandler_KeepaliveReapsDeadConnection verifies that a
+// connection which stops responding to pings (no pong, no other traffic)
+// has its read deadline expire and is reaped within the configured
+// PongWait, rather than lingering forever.
+func TestWebSocketHandler_KeepaliveReapsDeadConnection(t *testing.T) {
+	handler := NewWebSocketHandlerWithConfig(&mockTopicManager{}, WebSocketConfig{
+		PongWait:   200 * time.Millisecond,
+		PingPeriod: 50 * time.Millisecond,
+		WriteWait:  50 * time.Millisecond,
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Disable the client's automatic pong response so the server's pings
+	// go unanswered, simulating a peer that has stopped responding.
+	conn.SetPingHandler(func(string) error { return nil })
+
+	// Drain frames (including the welcome message and server pings) in the
+	// background without replying, rather than answering them.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		handler.connsMu.RLock()
+		n := len(handler.conns)
+		handler.connsMu.RUnlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("expected the unresponsive connection to be reaped within the keepalive window")
+}
+
+// TestWebSocketHandler_SubprotocolCodecRoundTrip connects with the
+// "pubsub.msgpack" Sec-WebSocket-Protocol and with none at all, and verifies the
+// welcome ServerMsg frame comes back in the negotiated wire format: JSON as
+// a text frame by default, msgpack as a binary frame tagged with the
+// negotiated content-type byte.
+func TestWebSocketHandler_SubprotocolCodecRoundTrip(t *testing.T) {
+	handler := NewWebSocketHandler(&mockTopicManager{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+
+	cases := []struct {
+		name       string
+		subproto   string
+		wantBinary bool
+	}{
+		{name: "default JSON", subproto: "", wantBinary: false},
+		{name: "msgpack", subproto: "pubsub.msgpack", wantBinary: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.subproto != "" {
+				header.Set("Sec-WebSocket-Protocol", tc.subproto)
+			}
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], header)
+			if err != nil {
+				t.Fatalf("dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage: %v", err)
+			}
+
+			var got models.ServerMsg
+			if tc.wantBinary {
+				if msgType != websocket.BinaryMessage {
+					t.Fatalf("expected a binary frame for subprotocol %q, got message type %d", tc.subproto, msgType)
+				}
+				if len(data) == 0 {
+					t.Fatal("expected a non-empty frame")
+				}
+				// First byte is the content-type tag written by writeServerMsg.
+				if err := msgpack.Unmarshal(data[1:], &got); err != nil {
+					t.Fatalf("msgpack.Unmarshal: %v", err)
+				}
+			} else {
+				if msgType != websocket.TextMessage {
+					t.Fatalf("expected a text frame for the default codec, got message type %d", msgType)
+				}
+				if err := json.Unmarshal(data, &got); err != nil {
+					t.Fatalf("json.Unmarshal: %v", err)
+				}
+			}
+
+			if got.Type != "connected" {
+				t.Errorf("expected welcome message type %q, got %q", "connected", got.Type)
+			}
+		})
+	}
+}
+
+// TestWebSocketHandler_SubscribeFromOldest_ReachesPastRing verifies that a
+// subscribe with from_oldest=true resolves to the topic's oldest durably
+// retained sequence and replays it even after the ring buffer itself has
+// evicted that message, by falling back to the WAL.
+func TestWebSocketHandler_SubscribeFromOldest_ReachesPastRing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	ring, err := ringbuffer.NewRingBufferWithWAL(2, dir)
+	if err != nil {
+		t.Fatalf("NewRingBufferWithWAL failed: %v", err)
+	}
+	tp := topic.NewTopicWithRing("orders", ring)
+
+	for i := 1; i <= 5; i++ {
+		tp.Publish(models.Message{
+			ID:      string(rune('a' + i)),
+			Payload: json.RawMessage(`{"n":1}`),
+		}, topic.PolicyDropOldest)
+	}
+
+	if _, ok := tp.OldestSeq(); !ok {
+		t.Fatal("expected the ring to retain at least one message")
+	}
+	if oldest, _ := tp.OldestSeq(); oldest != 4 {
+		t.Fatalf("expected the ring to have evicted down to seq 4, got %d", oldest)
+	}
+
+	handler := NewWebSocketHandler(&mockTopicManager{topics: map[string]*topic.Topic{"orders": tp}})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+server.URL[4:], nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	var welcome models.ServerMsg
+	if err := conn.ReadJSON(&welcome); err != nil {
+		t.Fatalf("ReadJSON (welcome): %v", err)
+	}
+
+	sub := models.WSClientMsg{Type: MsgTypeSubscribe, Topic: "orders", FromOldest: true}
+	if err := conn.WriteJSON(sub); err != nil {
+		t.Fatalf("WriteJSON (subscribe): %v", err)
+	}
+
+	var first models.ServerMsg
+	if err := conn.ReadJSON(&first); err != nil {
+		t.Fatalf("ReadJSON (first replayed message): %v", err)
+	}
+	if first.Type != "message" || first.Message == nil {
+		t.Fatalf("expected a replayed message first, got %+v", first)
+	}
+	if first.Message.Seq != 1 {
+		t.Errorf("expected replay to start at seq 1 (from the WAL), got %d", first.Message.Seq)
+	}
+}
@@ -8,7 +8,13 @@ import (
 
 // RegisterSubscriberRoutes registers all subscriber service HTTP routes with the provided chi router.
 // This function mounts the following endpoints:
-//   - GET /ws - WebSocket endpoint for subscriber connections
+//   - GET /ws - WebSocket endpoint for subscriber connections. A client may
+//     request a MessagePack wire format by sending a Sec-WebSocket-Protocol
+//     header of "pubsub.msgpack", "pubsub.msgpack+gzip", or
+//     "pubsub.msgpack+br"; otherwise the connection uses JSON.
+//   - GET /topics/{name}/sse - Server-Sent Events endpoint for a single topic
+//   - GET /sse/{name} - alias of the above for clients expecting a
+//     top-level SSE path
 //
 // The function creates a new WebSocket handler with the provided subscriber service and
 // registers the WebSocket route.
@@ -19,4 +25,9 @@ func RegisterSubscriberRoutes(r chi.Router, svc subscriberService.SubscriberServ
 	// Create a new WebSocket handler
 	handler := NewWebSocketHandler(topicManager)
 	r.Get("/ws", handler.HandleWebSocket)
+
+	// Create a new SSE handler
+	sseHandler := NewSSEHandler(topicManager)
+	r.Get("/topics/{name}/sse", sseHandler.HandleSSE)
+	r.Get("/sse/{name}", sseHandler.HandleSSE)
 }
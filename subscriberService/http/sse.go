@@ -0,0 +1,207 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+)
+
+const (
+	// sseHeartbeatInterval is how often a comment line is written to an idle
+	// SSE connection so intermediaries and clients can tell it's still
+	// alive.
+	sseHeartbeatInterval = 15 * time.Second
+
+	// sseSendBuffer sizes the Subscriber.Send channel backing an SSE stream.
+	sseSendBuffer = 100
+)
+
+// SSEHandler serves topic subscriptions over Server-Sent Events, mirroring
+// the Mercure hub pattern: history is replayed from Last-Event-ID before the
+// connection switches to live tailing.
+type SSEHandler struct {
+	topicManager topicManagerService.TopicManager
+}
+
+// NewSSEHandler creates a new SSE handler with the specified topic manager.
+func NewSSEHandler(topicManager topicManagerService.TopicManager) *SSEHandler {
+	return &SSEHandler{topicManager: topicManager}
+}
+
+// HandleSSE handles GET /topics/{name}/sse requests. It honors the
+// Last-Event-ID request header (or a ?lastEventID= query param, for clients
+// such as curl that can't set it directly) to replay missed messages from
+// the topic's ring buffer or WAL before streaming live messages as they're
+// published.
+func (h *SSEHandler) HandleSSE(w http.ResponseWriter, r *http.Request) {
+	topicName := chi.URLParam(r, "name")
+	if topicName == "" {
+		http.Error(w, "Topic name is required", http.StatusBadRequest)
+		return
+	}
+
+	topic, exists := h.topicManager.GetTopic(topicName)
+	if !exists {
+		http.Error(w, "Topic not found", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	clientID := generateClientID()
+	sink := &sseSink{w: w, flusher: flusher}
+	sub := subscriber.NewSubscriberWithSink(clientID, sink, sseSendBuffer)
+
+	// Add the subscriber before replaying history so no message published
+	// from this point on can fall into the gap between the history replay
+	// and going live, mirroring handleSubscribe's WebSocket flow.
+	topic.AddSubscriber(sub)
+	defer func() {
+		// Signal that we've stopped reading Send before asking the topic to
+		// remove (and close) this subscriber, so Close doesn't deadlock
+		// waiting on Done.
+		close(sub.Done)
+		topic.RemoveSubscriber(clientID)
+	}()
+
+	var lastFlushed uint64
+	if lastEventID > 0 {
+		history, err := topic.ReplayFrom(lastEventID + 1)
+		if err != nil {
+			log.Printf("SSE client %s: replay from %d failed: %v", clientID, lastEventID+1, err)
+		}
+		for _, m := range history {
+			serverMsg := models.ServerMsg{
+				Type:    "message",
+				Topic:   topicName,
+				Message: &m,
+				Ts:      time.Now(),
+			}
+			if err := sink.WriteMessage(serverMsg, 0); err != nil {
+				return
+			}
+			if m.Seq > lastFlushed {
+				lastFlushed = m.Seq
+			}
+		}
+	}
+
+	log.Printf("SSE client %s subscribed to topic %q (from_seq=%d)", clientID, topicName, lastEventID)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg, ok := <-sub.Send:
+			if !ok {
+				return
+			}
+			if msg.Message != nil && msg.Message.Seq != 0 && msg.Message.Seq <= lastFlushed {
+				// Already delivered as part of the history replay above.
+				continue
+			}
+			if err := sink.WriteMessage(msg, 0); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// parseLastEventID reads the resume point for an SSE connection from the
+// Last-Event-ID header, falling back to a ?lastEventID= query param for
+// clients that can't set arbitrary headers. Returns zero (start from live
+// only) if neither is present.
+func parseLastEventID(r *http.Request) (uint64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("lastEventID")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Last-Event-ID %q", raw)
+	}
+	return id, nil
+}
+
+// sseSink adapts an http.ResponseWriter+Flusher pair to subscriber.Sink,
+// framing each message as an SSE event instead of a WebSocket text frame.
+type sseSink struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	mu      sync.Mutex
+}
+
+// WriteMessage implements subscriber.Sink. writeTimeout is ignored: an
+// http.ResponseWriter has no native write-deadline hook.
+func (s *sseSink) WriteMessage(msg models.ServerMsg, _ time.Duration) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal server message: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg.Message != nil && msg.Message.Seq != 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", msg.Message.Seq); err != nil {
+			return err
+		}
+	}
+	if msg.Type != "" {
+		if _, err := fmt.Fprintf(s.w, "event: %s\n", msg.Type); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+// Close implements subscriber.Sink. There is nothing to close on an
+// http.ResponseWriter; the handler returning ends the response.
+func (s *sseSink) Close() error {
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
@@ -17,9 +18,10 @@ import (
 
 // SubscriberServiceImpl implements the SubscriberService interface.
 type SubscriberServiceImpl struct {
-	registry *registry.Registry
-	cfg      *config.Config
-	topicMgr topicManagerService.TopicManager
+	registry   *registry.Registry
+	cfg        *config.Config
+	topicMgr   topicManagerService.TopicManager
+	metricsReg metrics.Registry
 
 	// Connection management
 	activeConnsMu sync.RWMutex
@@ -36,6 +38,7 @@ func NewSubscriberService(registry *registry.Registry, cfg *config.Config, topic
 		registry:    registry,
 		cfg:         cfg,
 		topicMgr:    topicMgr,
+		metricsReg:  registry.MetricsRegistry(),
 		activeConns: make(map[*websocket.Conn]struct{}),
 		clientSubs:  make(map[string]map[string]*subscriber.Subscriber),
 	}
@@ -81,16 +84,19 @@ func (s *SubscriberServiceImpl) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// Publish sends a message to a specific topic.
+// Publish sends a message to a specific topic, routed through the topic
+// manager so the configured overflow policy, pattern-subscriber fan-out,
+// and cluster forwarding all apply the same way they do for every other
+// publish path.
 func (s *SubscriberServiceImpl) Publish(topic string, msg models.Message) error {
-	// Get the topic from the registry
-	topicObj, exists := s.topicMgr.GetTopic(topic)
-	if !exists {
+	if _, exists := s.topicMgr.GetTopic(topic); !exists {
 		return fmt.Errorf("topic '%s' not found", topic)
 	}
 
-	// Publish the message
-	delivered, dropped := topicObj.Publish(msg, "DROP_OLDEST", 100)
+	delivered, dropped, err := s.topicMgr.Publish(topic, msg)
+	if err != nil {
+		return err
+	}
 
 	log.Printf("Published message to topic '%s': %d delivered, %d dropped", topic, delivered, dropped)
 	return nil
@@ -106,6 +112,7 @@ func (s *SubscriberServiceImpl) RegisterConnection(conn *websocket.Conn) {
 	s.activeConnsMu.Lock()
 	s.activeConns[conn] = struct{}{}
 	s.activeConnsMu.Unlock()
+	s.metricsReg.IncActiveConnections()
 }
 
 // UnregisterConnection removes a WebSocket connection.
@@ -113,6 +120,7 @@ func (s *SubscriberServiceImpl) UnregisterConnection(conn *websocket.Conn) {
 	s.activeConnsMu.Lock()
 	delete(s.activeConns, conn)
 	s.activeConnsMu.Unlock()
+	s.metricsReg.DecActiveConnections()
 }
 
 // RegisterClientSubscriber registers a subscriber for a specific client and topic.
@@ -3,25 +3,50 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // Handler provides HTTP handlers for topic management operations.
 type Handler struct {
 	topicManager topicManagerService.TopicManager
 	startTime    time.Time
+	defaultTTL   time.Duration
 }
 
-// NewHandler creates a new HTTP handler with the specified topic manager.
+// HandlerConfig configures optional Handler behavior. A zero value
+// reproduces NewHandler's defaults.
+type HandlerConfig struct {
+	// DefaultTTL is the idle-expiry duration applied to CreateTopic
+	// requests that omit "ttl". Zero means topics created without an
+	// explicit ttl are never reaped for being idle.
+	DefaultTTL time.Duration
+}
+
+// NewHandler creates a new HTTP handler with the specified topic manager
+// and no default TTL (see HandlerConfig.DefaultTTL).
 func NewHandler(topicManager topicManagerService.TopicManager) *Handler {
+	return NewHandlerWithConfig(topicManager, HandlerConfig{})
+}
+
+// NewHandlerWithConfig creates a new HTTP handler with the specified topic
+// manager and config.
+func NewHandlerWithConfig(topicManager topicManagerService.TopicManager, cfg HandlerConfig) *Handler {
 	return &Handler{
 		topicManager: topicManager,
 		startTime:    time.Now(),
+		defaultTTL:   cfg.DefaultTTL,
 	}
 }
 
@@ -38,16 +63,30 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Post("/", h.CreateTopic)
 		r.Get("/", h.ListTopics)
 		r.Delete("/{name}", h.DeleteTopic)
+		r.Get("/{name}/messages", h.ReplayMessages)
+		r.Post("/{name}/publish", h.Publish)
+		r.Post("/{name}/truncate", h.Truncate)
 	})
 
 	// Health and stats endpoints
 	r.Get("/health", h.Health)
 	r.Get("/stats", h.Stats)
+
+	// Prometheus metrics exposition, a no-op 404 unless the topic manager
+	// was configured with MetricsBackend "prometheus".
+	metrics.RegisterRoutes(r, h.topicManager.MetricsRegistry())
 }
 
 // CreateTopicRequest represents the request body for creating a topic.
 type CreateTopicRequest struct {
 	Name string `json:"name"`
+
+	// TTL, if set, is a duration string (e.g. "5m") after which the topic
+	// is automatically deleted once idle (no publish or subscribe
+	// activity) and without active subscribers. Omitted or empty means the
+	// handler's HandlerConfig.DefaultTTL, which is zero (no TTL) unless
+	// configured.
+	TTL string `json:"ttl,omitempty"`
 }
 
 // CreateTopicResponse represents the response for topic creation.
@@ -71,20 +110,28 @@ func (h *Handler) CreateTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ttl := h.defaultTTL
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			http.Error(w, "Invalid ttl", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Attempt to create the topic
-	err := h.topicManager.CreateTopic(req.Name)
+	err := h.topicManager.CreateTopicWithTTL(req.Name, ttl)
 	if err != nil {
 		switch err.Error() {
 		case "topic already exists":
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{
+			writeJSON(w, r, http.StatusConflict, map[string]string{
 				"error": "Topic already exists",
 				"topic": req.Name,
 			})
 			return
 		case "invalid topic name":
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{
+			writeJSON(w, r, http.StatusBadRequest, map[string]string{
 				"error": "Invalid topic name",
 				"topic": req.Name,
 			})
@@ -96,13 +143,10 @@ func (h *Handler) CreateTopic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Topic created successfully
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	response := CreateTopicResponse{
+	writeJSON(w, r, http.StatusCreated, CreateTopicResponse{
 		Message: "Topic created successfully",
 		Topic:   req.Name,
-	}
-	json.NewEncoder(w).Encode(response)
+	})
 }
 
 // DeleteTopic handles DELETE /topics/{name} requests.
@@ -119,15 +163,13 @@ func (h *Handler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		switch err.Error() {
 		case "topic not found":
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{
+			writeJSON(w, r, http.StatusNotFound, map[string]string{
 				"error": "Topic not found",
 				"topic": topicName,
 			})
 			return
 		case "invalid topic name":
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{
+			writeJSON(w, r, http.StatusBadRequest, map[string]string{
 				"error": "Invalid topic name",
 				"topic": topicName,
 			})
@@ -139,9 +181,7 @@ func (h *Handler) DeleteTopic(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Topic deleted successfully
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
+	writeJSON(w, r, http.StatusOK, map[string]string{
 		"message": "Topic deleted successfully",
 		"topic":   topicName,
 	})
@@ -156,13 +196,177 @@ type ListTopicsResponse struct {
 // Returns JSON response with list of all topics.
 func (h *Handler) ListTopics(w http.ResponseWriter, r *http.Request) {
 	topics := h.topicManager.ListTopics()
+	writeJSON(w, r, http.StatusOK, ListTopicsResponse{Topics: topics})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	response := ListTopicsResponse{
-		Topics: topics,
+// ReplayMessagesResponse represents the response for replaying a topic's
+// message history from a sequence offset.
+type ReplayMessagesResponse struct {
+	Topic    string           `json:"topic"`
+	Messages []models.Message `json:"messages"`
+}
+
+// ReplayMessages handles GET /topics/{name}/messages?from_seq=N&limit=M
+// requests. It returns every message with Seq >= from_seq, oldest first,
+// reading past the in-memory ring into the topic's WAL on disk when
+// needed, capped at limit messages if limit is given. Returns 404 if the
+// topic doesn't exist, 400 if from_seq or limit is present but not a
+// non-negative integer.
+func (h *Handler) ReplayMessages(w http.ResponseWriter, r *http.Request) {
+	topicName := chi.URLParam(r, "name")
+	if topicName == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Topic name is required")
+		return
+	}
+
+	t, exists := h.topicManager.GetTopic(topicName)
+	if !exists {
+		h.writeError(w, r, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	fromSeq := uint64(0)
+	if raw := r.URL.Query().Get("from_seq"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "from_seq must be a non-negative integer")
+			return
+		}
+		fromSeq = parsed
 	}
-	json.NewEncoder(w).Encode(response)
+
+	limit := -1
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			h.writeError(w, r, http.StatusBadRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = parsed
+	}
+
+	messages, err := t.ReplayFrom(fromSeq)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to replay messages")
+		return
+	}
+	if limit >= 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	writeJSON(w, r, http.StatusOK, ReplayMessagesResponse{
+		Topic:    topicName,
+		Messages: messages,
+	})
+}
+
+// TruncateResponse represents the response for a log compaction request.
+type TruncateResponse struct {
+	Topic  string `json:"topic"`
+	Before uint64 `json:"before"`
+}
+
+// Truncate handles POST /topics/{name}/truncate?before=N requests,
+// compacting the topic's on-disk WAL by discarding whole segments that
+// hold nothing at or after seq N. Returns 404 if the topic doesn't exist,
+// 400 if before is missing or not a non-negative integer.
+func (h *Handler) Truncate(w http.ResponseWriter, r *http.Request) {
+	topicName := chi.URLParam(r, "name")
+	if topicName == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Topic name is required")
+		return
+	}
+
+	t, exists := h.topicManager.GetTopic(topicName)
+	if !exists {
+		h.writeError(w, r, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	raw := r.URL.Query().Get("before")
+	if raw == "" {
+		h.writeError(w, r, http.StatusBadRequest, "before is required")
+		return
+	}
+	before, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "before must be a non-negative integer")
+		return
+	}
+
+	if err := t.TruncateBefore(before); err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, "Failed to truncate topic log")
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, TruncateResponse{Topic: topicName, Before: before})
+}
+
+// PublishRequest represents the request body for publishing a message.
+type PublishRequest struct {
+	ID         string            `json:"id"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Payload    json.RawMessage   `json:"payload"`
+}
+
+// PublishResponse represents the response for a publish request.
+type PublishResponse struct {
+	Delivered int `json:"delivered"`
+	Dropped   int `json:"dropped"`
+}
+
+// Publish handles POST /topics/{name}/publish requests. It accepts either a
+// JSON body {"id": "...", "attributes": {...}, "payload": ...} (Content-Type
+// application/json) or, for curl-friendly publishing, any other
+// Content-Type, whose raw body is used as the payload verbatim. In both
+// cases id is optional and auto-generated when omitted.
+// Returns 404 if the topic doesn't exist, 400 if the body can't be read.
+func (h *Handler) Publish(w http.ResponseWriter, r *http.Request) {
+	topicName := chi.URLParam(r, "name")
+	if topicName == "" {
+		h.writeError(w, r, http.StatusBadRequest, "Topic name is required")
+		return
+	}
+
+	var req PublishRequest
+	if isJSONContentType(r.Header.Get("Content-Type")) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "Invalid JSON")
+			return
+		}
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		payload, err := json.Marshal(string(body))
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "Failed to encode request body as payload")
+			return
+		}
+		req.Payload = payload
+	}
+	if req.ID == "" {
+		req.ID = generateMessageID()
+	}
+
+	if _, exists := h.topicManager.GetTopic(topicName); !exists {
+		h.writeError(w, r, http.StatusNotFound, "Topic not found")
+		return
+	}
+
+	msg := models.Message{ID: req.ID, Attributes: req.Attributes, Payload: req.Payload}
+	// Publish through the topic manager, not the topic directly, so the
+	// configured overflow policy, pattern-subscriber fan-out, and cluster
+	// forwarding all apply the same way they do for every other publish path.
+	delivered, dropped, err := h.topicManager.Publish(topicName, msg)
+	if err != nil {
+		h.writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to publish: %v", err))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, PublishResponse{Delivered: delivered, Dropped: dropped})
 }
 
 // HealthResponse represents the health check response.
@@ -196,9 +400,7 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 		Timestamp:        time.Now().Format(time.RFC3339),
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, r, http.StatusOK, response)
 }
 
 // Stats handles GET /stats requests.
@@ -206,9 +408,7 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
 	stats := h.topicManager.Stats()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
 		"topics":    stats,
 		"timestamp": time.Now().Format(time.RFC3339),
 	})
@@ -222,15 +422,65 @@ type ErrorResponse struct {
 }
 
 // writeError writes a standardized error response.
-func (h *Handler) writeError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := ErrorResponse{
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
+	writeJSON(w, r, statusCode, ErrorResponse{
 		Error:   http.StatusText(statusCode),
 		Message: message,
 		Code:    statusCode,
+	})
+}
+
+// acceptsMsgpack reports whether r's Accept header names application/msgpack
+// as an acceptable response media type.
+func acceptsMsgpack(r *http.Request) bool {
+	if r == nil {
+		return false
 	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if strings.EqualFold(mediaType, "application/msgpack") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSON writes v as the response body with statusCode, encoding it as
+// MessagePack instead of the default JSON when r carries an
+// "Accept: application/msgpack" header. r may be nil, in which case the
+// response is always JSON.
+func writeJSON(w http.ResponseWriter, r *http.Request, statusCode int, v interface{}) {
+	if acceptsMsgpack(r) {
+		b, err := msgpack.Marshal(v)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/msgpack")
+		w.WriteHeader(statusCode)
+		w.Write(b)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// isJSONContentType reports whether contentType names the JSON media type,
+// ignoring parameters like charset (e.g. "application/json; charset=utf-8").
+// An empty Content-Type is treated as JSON, matching the historical
+// behavior of this endpoint before it also accepted raw bodies.
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return strings.EqualFold(mediaType, "application/json")
+}
 
-	json.NewEncoder(w).Encode(response)
+// generateMessageID generates a message ID for a publish request that
+// didn't supply one.
+func generateMessageID() string {
+	return fmt.Sprintf("msg-%d", time.Now().UnixNano())
 }
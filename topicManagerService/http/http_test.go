@@ -4,18 +4,24 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
 	"github.com/tanmay-xvx/inmem-pubsub/topicManagerService"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // mockTopicManager is a mock implementation for testing
 type mockTopicManager struct {
-	topics map[string]bool
+	topics     map[string]bool
+	realTopics map[string]*topic.Topic
 }
 
 func (m *mockTopicManager) CreateTopic(name string) error {
@@ -29,6 +35,14 @@ func (m *mockTopicManager) CreateTopic(name string) error {
 	return nil
 }
 
+func (m *mockTopicManager) CreateTopicWithTTL(name string, ttl time.Duration) error {
+	return m.CreateTopic(name)
+}
+
+func (m *mockTopicManager) CreateTopicWithOptions(name string, opts topicManagerService.TopicOptions) error {
+	return m.CreateTopicWithTTL(name, opts.TTL)
+}
+
 func (m *mockTopicManager) DeleteTopic(name string) error {
 	if name == "" {
 		return ErrInvalidTopicName
@@ -54,10 +68,27 @@ func (m *mockTopicManager) ListTopics() []topicManagerService.TopicInfo {
 }
 
 func (m *mockTopicManager) GetTopic(name string) (*topic.Topic, bool) {
-	if m.topics[name] {
-		return nil, true
+	if !m.topics[name] {
+		return nil, false
+	}
+	if m.realTopics == nil {
+		m.realTopics = make(map[string]*topic.Topic)
+	}
+	if t, ok := m.realTopics[name]; ok {
+		return t, true
+	}
+	t := topic.NewTopic(name, 10)
+	m.realTopics[name] = t
+	return t, true
+}
+
+func (m *mockTopicManager) Publish(name string, msg models.Message) (delivered, dropped int, err error) {
+	t, exists := m.GetTopic(name)
+	if !exists {
+		return 0, 0, ErrTopicNotFound
 	}
-	return nil, false
+	delivered, dropped = t.Publish(msg, topic.DropOldest())
+	return delivered, dropped, nil
 }
 
 func (m *mockTopicManager) Stats() map[string]topicManagerService.TopicStats {
@@ -73,6 +104,10 @@ func (m *mockTopicManager) Stats() map[string]topicManagerService.TopicStats {
 	return stats
 }
 
+func (m *mockTopicManager) MetricsRegistry() metrics.Registry {
+	return metrics.Noop()
+}
+
 // Error constants for testing
 var (
 	ErrInvalidTopicName   = errors.New("invalid topic name")
@@ -198,6 +233,77 @@ func TestDeleteTopic_Success(t *testing.T) {
 	}
 }
 
+func TestPublish_JSONBody_AutoGeneratesID(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("POST", "/topics/orders/publish", bytes.NewBufferString(`{"payload":{"n":1}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response PublishResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+}
+
+func TestPublish_RawBody_NonJSONContentType(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("POST", "/topics/orders/publish", bytes.NewBufferString("hello from curl"))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	replayReq := httptest.NewRequest("GET", "/topics/orders/messages?from_seq=0", nil)
+	replayRec := httptest.NewRecorder()
+	router.ServeHTTP(replayRec, replayReq)
+
+	var replayResp ReplayMessagesResponse
+	if err := json.NewDecoder(replayRec.Body).Decode(&replayResp); err != nil {
+		t.Fatalf("Failed to decode replay response: %v", err)
+	}
+	if len(replayResp.Messages) != 1 {
+		t.Fatalf("Expected 1 published message, got %d", len(replayResp.Messages))
+	}
+
+	var payload string
+	if err := json.Unmarshal(replayResp.Messages[0].Payload, &payload); err != nil {
+		t.Fatalf("Expected payload to be a JSON string, got %q: %v", replayResp.Messages[0].Payload, err)
+	}
+	if payload != "hello from curl" {
+		t.Errorf("Expected payload %q, got %q", "hello from curl", payload)
+	}
+	if replayResp.Messages[0].ID == "" {
+		t.Error("Expected an auto-generated message ID")
+	}
+}
+
+func TestPublish_NotFound(t *testing.T) {
+	_, router := setupTestHandler()
+
+	req := httptest.NewRequest("POST", "/topics/missing/publish", bytes.NewBufferString(`{"payload":{"n":1}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
 func TestDeleteTopic_NotFound(t *testing.T) {
 	_, router := setupTestHandler()
 
@@ -210,6 +316,149 @@ func TestDeleteTopic_NotFound(t *testing.T) {
 	}
 }
 
+func TestReplayMessages_Success(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("GET", "/topics/orders/messages?from_seq=0", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response ReplayMessagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Topic != "orders" {
+		t.Errorf("Expected topic 'orders', got '%s'", response.Topic)
+	}
+	if len(response.Messages) != 0 {
+		t.Errorf("Expected no messages for an empty topic, got %d", len(response.Messages))
+	}
+}
+
+func TestReplayMessages_NotFound(t *testing.T) {
+	_, router := setupTestHandler()
+
+	req := httptest.NewRequest("GET", "/topics/missing/messages", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestReplayMessages_InvalidFromSeq(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("GET", "/topics/orders/messages?from_seq=not-a-number", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestReplayMessages_Limit(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+	for i := 0; i < 3; i++ {
+		body := fmt.Sprintf(`{"id":"m%d","payload":{"n":1}}`, i)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics/orders/publish", bytes.NewBufferString(body)))
+	}
+
+	req := httptest.NewRequest("GET", "/topics/orders/messages?from_seq=0&limit=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response ReplayMessagesResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Messages) != 2 {
+		t.Errorf("Expected limit to cap at 2 messages, got %d", len(response.Messages))
+	}
+}
+
+func TestReplayMessages_InvalidLimit(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("GET", "/topics/orders/messages?limit=-1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTruncate_Success(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+	for i := 0; i < 3; i++ {
+		body := fmt.Sprintf(`{"id":"m%d","payload":{"n":1}}`, i)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics/orders/publish", bytes.NewBufferString(body)))
+	}
+
+	req := httptest.NewRequest("POST", "/topics/orders/truncate?before=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response TruncateResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Topic != "orders" || response.Before != 2 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestTruncate_NotFound(t *testing.T) {
+	_, router := setupTestHandler()
+
+	req := httptest.NewRequest("POST", "/topics/missing/truncate?before=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestTruncate_MissingBefore(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"orders"}`)))
+
+	req := httptest.NewRequest("POST", "/topics/orders/truncate", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
 func TestListTopics_Success(t *testing.T) {
 	_, router := setupTestHandler()
 
@@ -235,6 +484,32 @@ func TestListTopics_Success(t *testing.T) {
 	}
 }
 
+func TestListTopics_MsgpackAccept(t *testing.T) {
+	_, router := setupTestHandler()
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/topics", bytes.NewBufferString(`{"name":"topic-1"}`)))
+
+	req := httptest.NewRequest("GET", "/topics", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack" {
+		t.Errorf("Expected Content-Type application/msgpack, got %q", ct)
+	}
+
+	var response ListTopicsResponse
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode msgpack response: %v", err)
+	}
+	if len(response.Topics) != 1 {
+		t.Errorf("Expected 1 topic, got %d", len(response.Topics))
+	}
+}
+
 func TestHealth_Success(t *testing.T) {
 	_, router := setupTestHandler()
 
@@ -316,6 +591,20 @@ func TestRegisterRoutes(t *testing.T) {
 	}
 }
 
+func TestMetrics_NoopBackend(t *testing.T) {
+	_, router := setupTestHandler()
+
+	// The mock manager's MetricsRegistry is Noop, so /metrics has nothing
+	// to scrape.
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a noop metrics backend, got %d", w.Code)
+	}
+}
+
 func TestMiddleware(t *testing.T) {
 	_, router := setupTestHandler()
 
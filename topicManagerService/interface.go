@@ -2,23 +2,70 @@
 package topicManagerService
 
 import (
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
 )
 
 // TopicInfo provides basic information about a topic for listing and monitoring.
 type TopicInfo struct {
-	Name        string `json:"name"`
-	Subscribers int    `json:"subscribers"`
-	Messages    uint64 `json:"messages"`
-	Dropped     uint64 `json:"dropped"`
+	Name         string        `json:"name"`
+	Subscribers  int           `json:"subscribers"`
+	Messages     uint64        `json:"messages"`
+	Dropped      uint64        `json:"dropped"`
+	TTL          time.Duration `json:"ttl,omitempty"`
+	LastActivity string        `json:"last_activity,omitempty"`
+
+	// ExpiresAt is when this topic will become eligible for idle reaping
+	// if it sees no further activity, formatted like LastActivity. Empty
+	// when TTL is zero.
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	// LastSeq is the sequence number of the most recently published
+	// message, or 0 if the topic has published nothing yet.
+	LastSeq uint64 `json:"last_seq,omitempty"`
 }
 
 // TopicStats provides detailed statistics for a topic.
 type TopicStats struct {
-	Name        string `json:"name"`
-	Subscribers int    `json:"subscribers"`
-	Messages    uint64 `json:"messages"`
-	Dropped     uint64 `json:"dropped"`
+	Name         string        `json:"name"`
+	Subscribers  int           `json:"subscribers"`
+	Messages     uint64        `json:"messages"`
+	Dropped      uint64        `json:"dropped"`
+	TTL          time.Duration `json:"ttl,omitempty"`
+	LastActivity string        `json:"last_activity,omitempty"`
+
+	// ExpiresAt is when this topic will become eligible for idle reaping
+	// if it sees no further activity, formatted like LastActivity. Empty
+	// when TTL is zero.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// TopicOptions bundles the optional per-topic settings accepted by
+// CreateTopicWithOptions. The zero value matches CreateTopic's defaults.
+type TopicOptions struct {
+	// TTL, if positive, is the idle-expiry duration for the new topic.
+	// Zero means the manager's configured default TTL applies.
+	TTL time.Duration
+}
+
+// DefaultMinCompressSize is the smallest marshaled payload, in bytes, that
+// SubscribeOptions.MinCompressSize defaults to when left at zero; smaller
+// messages are sent uncompressed regardless of the negotiated encoding.
+const DefaultMinCompressSize = 256
+
+// SubscribeOptions configures how a subscription's messages are delivered,
+// independent of which transport (WebSocket, SSE, ...) carries them.
+type SubscribeOptions struct {
+	// Compression selects the negotiated payload encoding: "identity",
+	// "gzip", "deflate", or "br". Empty means "identity".
+	Compression string
+
+	// MinCompressSize is the smallest marshaled payload worth compressing.
+	// Zero means DefaultMinCompressSize.
+	MinCompressSize int
 }
 
 // TopicManager defines the interface for topic management operations.
@@ -29,6 +76,20 @@ type TopicManager interface {
 	// Returns an error if the topic already exists or if the name is invalid.
 	CreateTopic(name string) error
 
+	// CreateTopicWithTTL creates a new topic that is automatically deleted
+	// once it has had no publish or subscribe activity for longer than ttl
+	// and has no active subscribers. A zero ttl means the topic is never
+	// reaped for being idle.
+	// Returns an error if the topic already exists or if the name is invalid.
+	CreateTopicWithTTL(name string, ttl time.Duration) error
+
+	// CreateTopicWithOptions creates a new topic with the specified name,
+	// applying opts. It is the extension point for per-topic settings
+	// beyond TTL; a zero opts.TTL falls back to the manager's configured
+	// default TTL, the same as CreateTopic.
+	// Returns an error if the topic already exists or if the name is invalid.
+	CreateTopicWithOptions(name string, opts TopicOptions) error
+
 	// DeleteTopic deletes a topic and notifies all subscribers.
 	// All subscribers are closed and removed from the topic.
 	// Returns an error if the topic doesn't exist or if the name is invalid.
@@ -43,8 +104,20 @@ type TopicManager interface {
 	// Returns (nil, false) if the topic doesn't exist.
 	GetTopic(name string) (*topic.Topic, bool)
 
+	// Publish publishes msg to topicName through the configured transport
+	// and overflow policy, delivering it to local subscribers (including
+	// any matched via a hierarchical/wildcard pattern) and forwarding it to
+	// peer nodes in cluster mode. Returns how many subscribers received it
+	// and how many were dropped to overflow, or the transport's error (the
+	// memory transport returns one if topicName doesn't exist).
+	Publish(topicName string, msg models.Message) (delivered, dropped int, err error)
+
 	// Stats returns detailed statistics for all topics.
 	// The returned map contains comprehensive topic statistics
 	// indexed by topic name.
 	Stats() map[string]TopicStats
+
+	// MetricsRegistry returns the metrics.Registry backing this manager's
+	// topics, for mounting the Prometheus exposition endpoint.
+	MetricsRegistry() metrics.Registry
 }
@@ -3,7 +3,10 @@ package topicManagerService
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
 )
 
@@ -30,6 +33,14 @@ func (m *mockTopicManager) CreateTopic(name string) error {
 	return nil
 }
 
+func (m *mockTopicManager) CreateTopicWithTTL(name string, ttl time.Duration) error {
+	return m.CreateTopic(name)
+}
+
+func (m *mockTopicManager) CreateTopicWithOptions(name string, opts TopicOptions) error {
+	return m.CreateTopicWithTTL(name, opts.TTL)
+}
+
 func (m *mockTopicManager) DeleteTopic(name string) error {
 	if name == "" {
 		return ErrInvalidTopicName
@@ -62,6 +73,13 @@ func (m *mockTopicManager) GetTopic(name string) (*topic.Topic, bool) {
 	return nil, false
 }
 
+func (m *mockTopicManager) Publish(name string, msg models.Message) (delivered, dropped int, err error) {
+	if !m.topics[name] {
+		return 0, 0, ErrTopicNotFound
+	}
+	return 0, 0, nil
+}
+
 func (m *mockTopicManager) Stats() map[string]TopicStats {
 	stats := make(map[string]TopicStats)
 	for name := range m.topics {
@@ -75,6 +93,10 @@ func (m *mockTopicManager) Stats() map[string]TopicStats {
 	return stats
 }
 
+func (m *mockTopicManager) MetricsRegistry() metrics.Registry {
+	return metrics.Noop()
+}
+
 // TestTopicManagerInterface tests that the interface can be implemented and used
 func TestTopicManagerInterface(t *testing.T) {
 	var tm TopicManager = &mockTopicManager{
@@ -2,33 +2,179 @@
 package topicManagerService
 
 import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/cluster"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
 	"github.com/tanmay-xvx/inmem-pubsub/internals/topic"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/transport"
 )
 
-// TopicManagerServiceImpl implements the TopicManager interface using the registry.
+// TopicManagerServiceImpl implements the TopicManager interface using the
+// registry for topic lifecycle (create, delete, list, stats) and a
+// transport.Transport for publish, subscribe, and history, so the storage
+// backing message delivery can be swapped independently of topic management.
 type TopicManagerServiceImpl struct {
-	registry *registry.Registry
-	cfg      *config.Config
-	metrics  *metrics.Metrics
+	registry  *registry.Registry
+	cfg       *config.Config
+	metrics   *metrics.Metrics
+	transport transport.Transport
+	cluster   cluster.ClusterTransport
+
+	reapStop chan struct{}
+	reapDone chan struct{}
+}
+
+// NewTopicManagerService creates a new topic manager service with the specified
+// dependencies and starts its background reaper goroutine, which periodically
+// deletes idle, TTL-expired topics and AckMode messages past their ack
+// deadline, and (when cfg.ClusterBindAddr is set) gossips topic interest and
+// forwards publishes to peer nodes. Call Close to stop both.
+func NewTopicManagerService(registry *registry.Registry, cfg *config.Config, metrics *metrics.Metrics, t transport.Transport) *TopicManagerServiceImpl {
+	s := &TopicManagerServiceImpl{
+		registry:  registry,
+		cfg:       cfg,
+		metrics:   metrics,
+		transport: t,
+		cluster:   cluster.Noop{},
+		reapStop:  make(chan struct{}),
+		reapDone:  make(chan struct{}),
+	}
+
+	if cfg.ClusterBindAddr != "" {
+		ct, err := cluster.New(cluster.Config{
+			NodeID:         cfg.ClusterNodeID,
+			BindAddr:       cfg.ClusterBindAddr,
+			Seeds:          splitSeeds(cfg.ClusterSeeds),
+			GossipInterval: cfg.ClusterGossipInterval,
+		}, func(topicName string, msg models.Message) error {
+			_, _, err := s.Publish(topicName, msg)
+			return err
+		})
+		if err != nil {
+			log.Printf("topicManagerService: cluster mode disabled: %v", err)
+		} else {
+			s.cluster = ct
+		}
+	}
+
+	go s.reapLoop()
+	return s
+}
+
+func splitSeeds(seeds string) []string {
+	if seeds == "" {
+		return nil
+	}
+	parts := strings.Split(seeds, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Publish publishes a message to topicName through the configured
+// transport, delivering it to local subscribers, and forwards it to peer
+// nodes with subscribers for topicName unless msg already arrived forwarded
+// from one (see models.Message.Origin). Returns how many local subscribers
+// received it and how many were dropped to overflow.
+func (s *TopicManagerServiceImpl) Publish(topicName string, msg models.Message) (delivered, dropped int, err error) {
+	delivered, dropped, err = s.transport.Publish(topicName, msg)
+	if err != nil {
+		return 0, 0, err
+	}
+	s.cluster.Forward(topicName, msg)
+	return delivered, dropped, nil
 }
 
-// NewTopicManagerService creates a new topic manager service with the specified dependencies.
-func NewTopicManagerService(registry *registry.Registry, cfg *config.Config, metrics *metrics.Metrics) *TopicManagerServiceImpl {
-	return &TopicManagerServiceImpl{
-		registry: registry,
-		cfg:      cfg,
-		metrics:  metrics,
+// Subscribe returns a channel of messages for topicName through the
+// configured transport. See transport.Transport.Subscribe for semantics.
+func (s *TopicManagerServiceImpl) Subscribe(ctx context.Context, topicName string, opts transport.SubscribeOptions) (<-chan models.Message, error) {
+	return s.transport.Subscribe(ctx, topicName, opts)
+}
+
+// History returns buffered messages for topicName through the configured
+// transport. See transport.Transport.History for semantics.
+func (s *TopicManagerServiceImpl) History(topicName string, from uint64, limit int) ([]models.Message, error) {
+	return s.transport.History(topicName, from, limit)
+}
+
+// reapLoop periodically scans the registry for idle, TTL-expired topics to
+// delete and AckMode deliveries past their acknowledgement deadline to
+// redeliver or dead-letter, until Close is called.
+func (s *TopicManagerServiceImpl) reapLoop() {
+	defer close(s.reapDone)
+
+	ticker := time.NewTicker(s.cfg.TopicReapInterval)
+	defer ticker.Stop()
+
+	ackTicker := time.NewTicker(s.cfg.AckReapInterval)
+	defer ackTicker.Stop()
+
+	interestTicker := time.NewTicker(s.cfg.ClusterGossipInterval)
+	defer interestTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, name := range s.registry.ExpiredTopics(time.Now()) {
+				if err := s.registry.DeleteTopic(name); err != nil {
+					log.Printf("Error reaping idle topic %s: %v", name, err)
+				} else {
+					s.registry.MetricsRegistry().ObserveTopicExpired(name)
+					log.Printf("Reaped idle topic: %s", name)
+				}
+			}
+		case <-ackTicker.C:
+			s.registry.ReapAckTimeouts()
+		case <-interestTicker.C:
+			for _, t := range s.registry.ListTopics() {
+				s.cluster.UpdateInterest(t.Name, t.Subscribers > 0)
+			}
+		case <-s.reapStop:
+			return
+		}
 	}
 }
 
-// CreateTopic creates a new topic with the specified name.
+// Close stops the background reaper goroutine, waits for it to exit, closes
+// the cluster transport (if cluster mode is enabled), and closes the
+// underlying transport.
+func (s *TopicManagerServiceImpl) Close() error {
+	close(s.reapStop)
+	<-s.reapDone
+	s.cluster.Close()
+	return s.transport.Close()
+}
+
+// CreateTopic creates a new topic with the specified name and the
+// registry's configured default TTL (cfg.DefaultTopicTTL, zero meaning no
+// TTL).
 func (s *TopicManagerServiceImpl) CreateTopic(name string) error {
 	return s.registry.CreateTopic(name)
 }
 
+// CreateTopicWithTTL creates a new topic that is automatically reaped once
+// idle for longer than ttl.
+func (s *TopicManagerServiceImpl) CreateTopicWithTTL(name string, ttl time.Duration) error {
+	return s.registry.CreateTopicWithTTL(name, ttl)
+}
+
+// CreateTopicWithOptions creates a new topic with the specified name,
+// applying opts.
+func (s *TopicManagerServiceImpl) CreateTopicWithOptions(name string, opts TopicOptions) error {
+	return s.registry.CreateTopicWithOptions(name, registry.TopicOptions{TTL: opts.TTL})
+}
+
 // DeleteTopic deletes a topic with the specified name.
 func (s *TopicManagerServiceImpl) DeleteTopic(name string) error {
 	return s.registry.DeleteTopic(name)
@@ -40,10 +186,14 @@ func (s *TopicManagerServiceImpl) ListTopics() []TopicInfo {
 	topics := make([]TopicInfo, len(registryTopics))
 	for i, rt := range registryTopics {
 		topics[i] = TopicInfo{
-			Name:        rt.Name,
-			Subscribers: rt.Subscribers,
-			Messages:    rt.Messages,
-			Dropped:     rt.Dropped,
+			Name:         rt.Name,
+			Subscribers:  rt.Subscribers,
+			Messages:     rt.Messages,
+			Dropped:      rt.Dropped,
+			TTL:          rt.TTL,
+			LastActivity: rt.LastActivity,
+			ExpiresAt:    rt.ExpiresAt,
+			LastSeq:      rt.LastSeq,
 		}
 	}
 	return topics
@@ -54,16 +204,25 @@ func (s *TopicManagerServiceImpl) GetTopic(name string) (*topic.Topic, bool) {
 	return s.registry.GetTopic(name)
 }
 
+// MetricsRegistry returns the metrics.Registry resolved from
+// cfg.MetricsBackend, for mounting metrics.RegisterRoutes.
+func (s *TopicManagerServiceImpl) MetricsRegistry() metrics.Registry {
+	return s.registry.MetricsRegistry()
+}
+
 // Stats returns statistics for all topics.
 func (s *TopicManagerServiceImpl) Stats() map[string]TopicStats {
 	registryStats := s.registry.Stats()
 	stats := make(map[string]TopicStats)
 	for name, rs := range registryStats {
 		stats[name] = TopicStats{
-			Name:        rs.Name,
-			Subscribers: rs.Subscribers,
-			Messages:    rs.Messages,
-			Dropped:     rs.Dropped,
+			Name:         rs.Name,
+			Subscribers:  rs.Subscribers,
+			Messages:     rs.Messages,
+			Dropped:      rs.Dropped,
+			TTL:          rs.TTL,
+			LastActivity: rs.LastActivity,
+			ExpiresAt:    rs.ExpiresAt,
 		}
 	}
 	return stats
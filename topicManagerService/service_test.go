@@ -0,0 +1,260 @@
+package topicManagerService
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanmay-xvx/inmem-pubsub/internals/config"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/metrics"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/models"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/registry"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/subscriber"
+	"github.com/tanmay-xvx/inmem-pubsub/internals/transport"
+)
+
+// noopSink is a subscriber.Sink that discards every message, for tests that
+// only care about subscriber presence, not delivery.
+type noopSink struct{}
+
+func (noopSink) WriteMessage(models.ServerMsg, time.Duration) error { return nil }
+func (noopSink) Close() error                                       { return nil }
+
+func newTestService(t *testing.T, reapInterval time.Duration) *TopicManagerServiceImpl {
+	t.Helper()
+	cfg := config.NewConfig()
+	cfg.TopicReapInterval = reapInterval
+	cfg.AckReapInterval = time.Hour
+	cfg.ClusterGossipInterval = time.Hour
+
+	m := metrics.NewMetrics()
+	reg := registry.NewRegistry(cfg, m)
+	tr := transport.NewMemoryTransport(reg)
+	s := NewTopicManagerService(reg, cfg, m, tr)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestTopicManagerService_ReapLoop_NeverReapsTopicWithSubscribers(t *testing.T) {
+	s := newTestService(t, 20*time.Millisecond)
+
+	if err := s.CreateTopicWithTTL("busy", 20*time.Millisecond); err != nil {
+		t.Fatalf("CreateTopicWithTTL: %v", err)
+	}
+	tp, ok := s.GetTopic("busy")
+	if !ok {
+		t.Fatal("expected topic to exist")
+	}
+	sub := subscriber.NewSubscriberWithSink("client-1", noopSink{}, 1)
+	sub.StartWriter(context.Background(), 100*time.Millisecond)
+	tp.AddSubscriber(sub)
+
+	time.Sleep(200 * time.Millisecond)
+
+	if _, ok := s.GetTopic("busy"); !ok {
+		t.Error("expected topic with an active subscriber to never be reaped")
+	}
+}
+
+func TestTopicManagerService_ReapLoop_ReapsOneTickAfterFinalUnsubscribe(t *testing.T) {
+	const reapInterval = 20 * time.Millisecond
+	const ttl = 20 * time.Millisecond
+	s := newTestService(t, reapInterval)
+
+	if err := s.CreateTopicWithTTL("idle", ttl); err != nil {
+		t.Fatalf("CreateTopicWithTTL: %v", err)
+	}
+	tp, ok := s.GetTopic("idle")
+	if !ok {
+		t.Fatal("expected topic to exist")
+	}
+	sub := subscriber.NewSubscriberWithSink("client-1", noopSink{}, 1)
+	sub.StartWriter(context.Background(), 100*time.Millisecond)
+	tp.AddSubscriber(sub)
+
+	// The topic has an active subscriber, so it must survive past its TTL.
+	time.Sleep(3 * ttl)
+	if _, ok := s.GetTopic("idle"); !ok {
+		t.Fatal("expected topic with an active subscriber to still exist")
+	}
+
+	tp.RemoveSubscriber("client-1")
+
+	// Immediately after the final unsubscribe the topic is still within
+	// its TTL window.
+	if _, ok := s.GetTopic("idle"); !ok {
+		t.Error("expected topic to still exist immediately after unsubscribe")
+	}
+
+	// Once ttl has elapsed since that unsubscribe, the next reap tick
+	// deletes it.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := s.GetTopic("idle"); !ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected topic to be reaped one tick after its final unsubscribe past the TTL")
+}
+
+func TestTopicManagerService_ReapLoop_ObservesTopicExpiredMetric(t *testing.T) {
+	const reapInterval = 20 * time.Millisecond
+	const ttl = 20 * time.Millisecond
+
+	cfg := config.NewConfig()
+	cfg.TopicReapInterval = reapInterval
+	cfg.AckReapInterval = time.Hour
+	cfg.ClusterGossipInterval = time.Hour
+	cfg.MetricsBackend = "prometheus"
+
+	m := metrics.NewMetrics()
+	reg := registry.NewRegistry(cfg, m)
+	tr := transport.NewMemoryTransport(reg)
+	s := NewTopicManagerService(reg, cfg, m, tr)
+	defer s.Close()
+
+	if err := s.CreateTopicWithTTL("idle", ttl); err != nil {
+		t.Fatalf("CreateTopicWithTTL: %v", err)
+	}
+
+	prom, ok := s.MetricsRegistry().(*metrics.PrometheusRegistry)
+	if !ok {
+		t.Fatal("expected a *metrics.PrometheusRegistry")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		rec := httptest.NewRecorder()
+		prom.Handler().ServeHTTP(rec, req)
+		if strings.Contains(rec.Body.String(), `pubsub_topics_expired_total{topic="idle"} 1`) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected pubsub_topics_expired_total to be incremented once the idle topic was reaped")
+}
+
+// freeAddr returns an available loopback "host:port" by briefly binding
+// port 0 and releasing it.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("freeAddr: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func newClusteredTestService(t *testing.T, nodeID, bindAddr string, seeds []string) *TopicManagerServiceImpl {
+	t.Helper()
+
+	cfg := config.NewConfig()
+	cfg.TopicReapInterval = time.Hour
+	cfg.AckReapInterval = time.Hour
+	cfg.ClusterNodeID = nodeID
+	cfg.ClusterBindAddr = bindAddr
+	cfg.ClusterGossipInterval = 20 * time.Millisecond
+	for _, s := range seeds {
+		if cfg.ClusterSeeds != "" {
+			cfg.ClusterSeeds += ","
+		}
+		cfg.ClusterSeeds += s
+	}
+
+	m := metrics.NewMetrics()
+	reg := registry.NewRegistry(cfg, m)
+	tr := transport.NewMemoryTransport(reg)
+	s := NewTopicManagerService(reg, cfg, m, tr)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// TestTopicManagerService_Publish_ForwardsToClusterPeer verifies that a
+// message handed to Publish — the single entry point every HTTP/WS handler
+// now calls instead of reaching into topic.Topic.Publish directly — is
+// forwarded to a peer node with an interested subscriber, exercising the
+// same cluster.ClusterTransport.Forward path a real client publish takes.
+func TestTopicManagerService_Publish_ForwardsToClusterPeer(t *testing.T) {
+	addrA := freeAddr(t)
+	addrB := freeAddr(t)
+
+	a := newClusteredTestService(t, "a", addrA, []string{addrB})
+	b := newClusteredTestService(t, "b", addrB, []string{addrA})
+
+	if err := a.CreateTopic("orders"); err != nil {
+		t.Fatalf("a.CreateTopic: %v", err)
+	}
+	if err := b.CreateTopic("orders"); err != nil {
+		t.Fatalf("b.CreateTopic: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := b.Subscribe(ctx, "orders", transport.SubscribeOptions{ClientID: "sub-1"})
+	if err != nil {
+		t.Fatalf("b.Subscribe: %v", err)
+	}
+
+	// Give the gossip loop time to push B's new subscriber interest to A.
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, _, err := a.Publish("orders", models.Message{ID: "1", Payload: json.RawMessage(`{"n":1}`)}); err != nil {
+			t.Fatalf("a.Publish: %v", err)
+		}
+		select {
+		case msg := <-ch:
+			if msg.ID != "1" {
+				t.Errorf("expected forwarded message ID 1, got %s", msg.ID)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	t.Fatal("timed out waiting for a.Publish to forward to b's subscriber")
+}
+
+// TestTopicManagerService_Publish_DeliversToPatternSubscriber verifies that
+// a message handed to Publish — the single entry point every HTTP/WS
+// handler now calls instead of reaching into topic.Topic.Publish directly —
+// still reaches a hierarchical/wildcard subscriber registered via
+// registry.SubscribePattern, exercising the same trie-matching path a real
+// client publish takes.
+func TestTopicManagerService_Publish_DeliversToPatternSubscriber(t *testing.T) {
+	s := newTestService(t, time.Hour)
+
+	if err := s.CreateTopic("sensors/room1/temperature"); err != nil {
+		t.Fatalf("CreateTopic: %v", err)
+	}
+
+	sub := subscriber.NewSubscriber("client-1", nil, 10)
+	if err := s.registry.SubscribePattern("sensors/+/temperature", sub); err != nil {
+		t.Fatalf("SubscribePattern: %v", err)
+	}
+
+	delivered, dropped, err := s.Publish("sensors/room1/temperature", models.Message{ID: "msg-1", Payload: json.RawMessage(`{"v":1}`)})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if delivered != 1 || dropped != 0 {
+		t.Errorf("expected delivered=1, dropped=0, got delivered=%d, dropped=%d", delivered, dropped)
+	}
+
+	select {
+	case got := <-sub.Send:
+		if got.Message == nil || got.Message.ID != "msg-1" {
+			t.Errorf("expected to receive msg-1, got %+v", got)
+		}
+	default:
+		t.Fatal("expected the pattern-matched subscriber to receive a message")
+	}
+}